@@ -0,0 +1,244 @@
+package httpc
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type BalanceStrategy int
+
+const (
+	RoundRobin BalanceStrategy = iota
+	Random
+	LeastInflight
+	Weighted
+)
+
+// Endpoint is a base URL serving a logical service. Weight is only used by
+// the Weighted strategy and defaults to 1.
+type Endpoint struct {
+	URL    string
+	Weight int
+}
+
+type ServiceEndpoints struct {
+	Strategy  BalanceStrategy
+	Endpoints []Endpoint
+}
+
+// EndpointStat is a snapshot of an endpoint state, for metrics and debugging.
+// Err is set for endpoints with an invalid URL, which are never picked.
+type EndpointStat struct {
+	URL                 string
+	Inflight            int
+	ConsecutiveFailures int
+	Ejected             bool
+	Err                 error
+}
+
+type endpoint struct {
+	url           *url.URL
+	raw           string
+	weight        int
+	currentWeight int
+	inflight      int
+	failures      int
+	ejectedUntil  time.Time
+}
+
+type balancer struct {
+	mu          sync.Mutex
+	strategy    BalanceStrategy
+	endpoints   []*endpoint
+	next        int
+	rnd         *rand.Rand
+	maxFailures int
+	ejectFor    time.Duration
+	invalid     []EndpointStat
+	err         error
+}
+
+func newBalancer(service ServiceEndpoints, maxFailures int, ejectFor time.Duration) *balancer {
+	b := &balancer{
+		strategy:    service.Strategy,
+		rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		maxFailures: maxFailures,
+		ejectFor:    ejectFor,
+	}
+	for _, e := range service.Endpoints {
+		u, err := url.Parse(e.URL)
+		if err == nil && (u.Scheme == "" || u.Host == "") {
+			err = fmt.Errorf("missing scheme or host")
+		}
+		if err != nil {
+			err = fmt.Errorf("invalid endpoint %q: %w", e.URL, err)
+			b.invalid = append(b.invalid, EndpointStat{URL: e.URL, Err: err})
+			if b.err == nil {
+				b.err = err
+			}
+			continue
+		}
+		weight := e.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		b.endpoints = append(b.endpoints, &endpoint{url: u, raw: e.URL, weight: weight})
+	}
+	if len(b.endpoints) == 0 && b.err == nil {
+		b.err = fmt.Errorf("no endpoints")
+	}
+	return b
+}
+
+// pick selects an endpoint, skipping ejected endpoints and the ones already
+// tried by the current call whenever another one is available. A service
+// with an invalid endpoint fails every call, so a typo is not hidden by the
+// valid replicas.
+func (b *balancer) pick(tried map[*endpoint]struct{}) (*endpoint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	now := time.Now()
+	candidates := b.filter(func(e *endpoint) bool {
+		_, seen := tried[e]
+		return !seen && !e.ejectedUntil.After(now)
+	})
+	if len(candidates) == 0 {
+		candidates = b.filter(func(e *endpoint) bool {
+			return !e.ejectedUntil.After(now)
+		})
+	}
+	if len(candidates) == 0 {
+		// every endpoint is ejected, spread the load over all of them
+		candidates = b.endpoints
+	}
+
+	var selected *endpoint
+	switch b.strategy {
+	case Random:
+		selected = candidates[b.rnd.Intn(len(candidates))]
+	case LeastInflight:
+		offset := b.next % len(candidates)
+		b.next++
+		for i := range candidates {
+			e := candidates[(offset+i)%len(candidates)]
+			if selected == nil || e.inflight < selected.inflight {
+				selected = e
+			}
+		}
+	case Weighted:
+		// smooth weighted round robin
+		total := 0
+		for _, e := range candidates {
+			e.currentWeight += e.weight
+			total += e.weight
+			if selected == nil || e.currentWeight > selected.currentWeight {
+				selected = e
+			}
+		}
+		selected.currentWeight -= total
+	default:
+		selected = candidates[b.next%len(candidates)]
+		b.next++
+	}
+
+	selected.inflight++
+	return selected, nil
+}
+
+func (b *balancer) filter(keep func(*endpoint) bool) []*endpoint {
+	var endpoints []*endpoint
+	for _, e := range b.endpoints {
+		if keep(e) {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// done releases e and records the outcome of the attempt for the passive
+// health check.
+func (b *balancer) done(e *endpoint, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.inflight--
+	if !failed {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if b.maxFailures > 0 && e.failures >= b.maxFailures {
+		e.ejectedUntil = time.Now().Add(b.ejectFor)
+		e.failures = 0
+	}
+}
+
+func (b *balancer) release(e *endpoint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e.inflight--
+}
+
+func (b *balancer) stats() []EndpointStat {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]EndpointStat, 0, len(b.endpoints)+len(b.invalid))
+	for _, e := range b.endpoints {
+		stats = append(stats, EndpointStat{
+			URL:                 e.raw,
+			Inflight:            e.inflight,
+			ConsecutiveFailures: e.failures,
+			Ejected:             e.ejectedUntil.After(now),
+		})
+	}
+	return append(stats, b.invalid...)
+}
+
+func (c *HttpClient) balancerFor(addrs string) (*balancer, *url.URL) {
+	if len(c.balancers) == 0 {
+		return nil, nil
+	}
+	u, err := url.Parse(addrs)
+	if err != nil {
+		return nil, nil
+	}
+	b, ok := c.balancers[strings.ToLower(u.Host)]
+	if !ok {
+		return nil, nil
+	}
+	return b, u
+}
+
+// endpointURL rewrites u, addressed to a logical service, to the endpoint
+// base URL.
+func endpointURL(u *url.URL, e *endpoint) string {
+	target := *u
+	target.Scheme = e.url.Scheme
+	target.Host = e.url.Host
+	target.User = e.url.User
+	if base := strings.TrimRight(e.url.Path, "/"); base != "" {
+		target.Path = base + "/" + strings.TrimLeft(u.Path, "/")
+		target.RawPath = strings.TrimRight(e.url.EscapedPath(), "/") + "/" + strings.TrimLeft(u.EscapedPath(), "/")
+	}
+	return target.String()
+}
+
+// EndpointStats returns the state of the endpoints serving service.
+func (c *HttpClient) EndpointStats(service string) []EndpointStat {
+	b, ok := c.balancers[strings.ToLower(service)]
+	if !ok {
+		return nil
+	}
+	return b.stats()
+}
@@ -0,0 +1,119 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newNamedServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name + " " + r.URL.Path))
+	}))
+}
+
+func TestHttpClient_WithEndpointsRoundRobin(t *testing.T) {
+	a := newNamedServer("a")
+	defer a.Close()
+	b := newNamedServer("b")
+	defer b.Close()
+
+	client := NewHttpClient(WithEndpoints("users", RoundRobin,
+		Endpoint{URL: a.URL},
+		Endpoint{URL: b.URL + "/v2"},
+	))
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		_, body, err := client.Get("http://users/me")
+		assert.NoError(t, err)
+		got = append(got, string(body))
+	}
+	assert.Equal(t, []string{"a /me", "b /v2/me", "a /me", "b /v2/me"}, got)
+}
+
+func TestHttpClient_WithEndpointsFailsOver(t *testing.T) {
+	dead := newNamedServer("dead")
+	dead.Close()
+	live := newNamedServer("live")
+	defer live.Close()
+
+	client := NewHttpClient(
+		WithEndpoints("users", RoundRobin, Endpoint{URL: dead.URL}, Endpoint{URL: live.URL}),
+		WithPassiveHealthCheck(1, time.Minute),
+		WithMaxRetries(2),
+		WithMaxRetryWait(0),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, body, err := client.Get("http://users/me")
+		assert.NoError(t, err)
+		assert.Equal(t, "live /me", string(body))
+	}
+
+	stats := client.EndpointStats("users")
+	assert.Len(t, stats, 2)
+	assert.True(t, stats[0].Ejected)
+	assert.False(t, stats[1].Ejected)
+}
+
+func TestHttpClient_WithEndpointsInvalid(t *testing.T) {
+	client := NewHttpClient(WithEndpoints("users", RoundRobin, Endpoint{URL: "not a url"}))
+	_, _, err := client.Get("http://users/me")
+	assert.Error(t, err)
+}
+
+func TestHttpClient_WithEndpointsOneInvalid(t *testing.T) {
+	a := newNamedServer("a")
+	defer a.Close()
+
+	client := NewHttpClient(WithEndpoints("users", RoundRobin,
+		Endpoint{URL: a.URL},
+		Endpoint{URL: "users-2.local:8080"},
+	))
+	_, _, err := client.Get("http://users/me")
+	assert.ErrorContains(t, err, `invalid endpoint "users-2.local:8080"`)
+
+	stats := client.EndpointStats("users")
+	assert.Len(t, stats, 2)
+	assert.NoError(t, stats[0].Err)
+	assert.Equal(t, "users-2.local:8080", stats[1].URL)
+	assert.Error(t, stats[1].Err)
+}
+
+func TestBalancer_Strategies(t *testing.T) {
+	endpoints := []Endpoint{{URL: "http://a", Weight: 3}, {URL: "http://b", Weight: 1}}
+
+	weighted := newBalancer(ServiceEndpoints{Strategy: Weighted, Endpoints: endpoints}, 0, 0)
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		e, err := weighted.pick(nil)
+		assert.NoError(t, err)
+		counts[e.raw]++
+		weighted.done(e, false)
+	}
+	assert.Equal(t, map[string]int{"http://a": 6, "http://b": 2}, counts)
+
+	least := newBalancer(ServiceEndpoints{Strategy: LeastInflight, Endpoints: endpoints}, 0, 0)
+	first, _ := least.pick(nil)
+	second, _ := least.pick(nil)
+	assert.NotEqual(t, first, second)
+	least.done(first, false)
+	third, _ := least.pick(nil)
+	assert.Equal(t, first, third)
+
+	random := newBalancer(ServiceEndpoints{Strategy: Random, Endpoints: endpoints}, 0, 0)
+	e, _ := random.pick(nil)
+	other, _ := random.pick(map[*endpoint]struct{}{e: {}})
+	assert.NotEqual(t, e, other)
+}
+
+func TestEndpointURL(t *testing.T) {
+	u, _ := url.Parse("http://users/orders/a%2Fb?x=1")
+	b := newBalancer(ServiceEndpoints{Endpoints: []Endpoint{{URL: "https://10.0.0.1:8443/api/"}}}, 0, 0)
+	assert.Equal(t, "https://10.0.0.1:8443/api/orders/a%2Fb?x=1", endpointURL(u, b.endpoints[0]))
+}
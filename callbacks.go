@@ -0,0 +1,165 @@
+package httpc
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// AttemptInfo describes an attempt of a call. URL is the URL of the attempt,
+// which differs from the call URL when the call is load balanced, and Route
+// the template set with WithRouteTemplate.
+type AttemptInfo struct {
+	Method  string
+	URL     string
+	Route   string
+	Attempt int
+	Request *http.Request
+	Start   time.Time
+}
+
+// ResponseInfo describes the outcome of an attempt: either Response or Err is
+// set. The response body is not read yet.
+type ResponseInfo struct {
+	AttemptInfo
+	Response *http.Response
+	Err      error
+	Duration time.Duration
+}
+
+// RetryInfo describes the decision to retry a failed attempt. Err is why the
+// attempt failed, a *StatusError for unsuccessful responses, and Wait is the
+// time waited before the next attempt.
+type RetryInfo struct {
+	AttemptInfo
+	Err        error
+	StatusCode int
+	Duration   time.Duration
+	Wait       time.Duration
+}
+
+// CallInfo describes a call and its attempts.
+type CallInfo struct {
+	Method   string
+	URL      string
+	Route    string
+	Attempts int
+	Start    time.Time
+	Duration time.Duration
+}
+
+type (
+	AttemptStartFunc func(AttemptInfo)
+	ResponseFunc     func(ResponseInfo)
+	RetryFunc        func(RetryInfo)
+	ErrorFunc        func(CallInfo, error)
+	GiveUpFunc       func(CallInfo, *RetryError)
+	SuccessFunc      func(CallInfo, *http.Response)
+)
+
+// WithOnAttemptStart calls fn before each attempt is sent.
+func WithOnAttemptStart(fn AttemptStartFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if fn != nil {
+			s.OnAttemptStart = append(s.OnAttemptStart, fn)
+		}
+	}
+}
+
+// WithOnResponse calls fn with the response or transport error of each
+// attempt.
+func WithOnResponse(fn ResponseFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if fn != nil {
+			s.OnResponse = append(s.OnResponse, fn)
+		}
+	}
+}
+
+// WithOnRetry calls fn when a failed attempt is going to be retried, before
+// the retry wait.
+func WithOnRetry(fn RetryFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if fn != nil {
+			s.OnRetry = append(s.OnRetry, fn)
+		}
+	}
+}
+
+// WithOnError calls fn with the error of every failed call.
+func WithOnError(fn ErrorFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if fn != nil {
+			s.OnError = append(s.OnError, fn)
+		}
+	}
+}
+
+// WithOnGiveUp calls fn when a call stops retrying an error that could be
+// retried, because the retries, the time or the retry budget ran out or the
+// context is done. It is called before the OnError callbacks.
+func WithOnGiveUp(fn GiveUpFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if fn != nil {
+			s.OnGiveUp = append(s.OnGiveUp, fn)
+		}
+	}
+}
+
+// WithOnSuccess calls fn when a call succeeds.
+func WithOnSuccess(fn SuccessFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if fn != nil {
+			s.OnSuccess = append(s.OnSuccess, fn)
+		}
+	}
+}
+
+func (c *HttpClient) attemptStarted(info AttemptInfo) {
+	if c.params == nil {
+		return
+	}
+	for _, fn := range c.params.OnAttemptStart {
+		fn(info)
+	}
+}
+
+func (c *HttpClient) responseReceived(info ResponseInfo) {
+	if c.params == nil {
+		return
+	}
+	for _, fn := range c.params.OnResponse {
+		fn(info)
+	}
+}
+
+func (c *HttpClient) retrying(info RetryInfo) {
+	if c.params == nil {
+		return
+	}
+	for _, fn := range c.params.OnRetry {
+		fn(info)
+	}
+}
+
+func (c *HttpClient) callDone(call CallInfo, resp *http.Response, err error) {
+	if c.params == nil {
+		return
+	}
+	if err == nil {
+		for _, fn := range c.params.OnSuccess {
+			fn(call, resp)
+		}
+		return
+	}
+
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) && retryErr.Reason != StopNonRetryable {
+		for _, fn := range c.params.OnGiveUp {
+			fn(call, retryErr)
+		}
+	}
+	for _, fn := range c.params.OnError {
+		fn(call, err)
+	}
+}
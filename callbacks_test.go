@@ -0,0 +1,103 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_LifecycleCallbacks(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	var events []string
+	var retry RetryInfo
+	var done CallInfo
+	client := NewHttpClient(
+		WithMaxRetries(3),
+		WithRetryStatusCodes(http.StatusBadGateway),
+		WithMaxRetryWait(0),
+		WithOnAttemptStart(func(info AttemptInfo) {
+			assert.NotNil(t, info.Request)
+			events = append(events, "start")
+		}),
+		WithOnResponse(func(info ResponseInfo) {
+			assert.NoError(t, info.Err)
+			events = append(events, http.StatusText(info.Response.StatusCode))
+		}),
+		WithOnRetry(func(info RetryInfo) {
+			retry = info
+			events = append(events, "retry")
+		}),
+		WithOnSuccess(func(call CallInfo, resp *http.Response) {
+			done = call
+			events = append(events, "success")
+		}),
+		WithOnError(func(CallInfo, error) {
+			events = append(events, "error")
+		}),
+	)
+
+	_, body, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, []string{"start", "Bad Gateway", "retry", "start", "OK", "success"}, events)
+
+	assert.Equal(t, 1, retry.Attempt)
+	assert.Equal(t, http.StatusBadGateway, retry.StatusCode)
+	var statusErr *StatusError
+	assert.ErrorAs(t, retry.Err, &statusErr)
+
+	assert.Equal(t, http.MethodGet, done.Method)
+	assert.Equal(t, ts.URL, done.URL)
+	assert.Equal(t, 2, done.Attempts)
+	assert.True(t, done.Duration > 0)
+}
+
+func TestHttpClient_GiveUpCallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	var gaveUp []*RetryError
+	var errs []error
+	client := NewHttpClient(
+		WithMaxRetries(2),
+		WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithMaxRetryWait(0),
+		WithOnGiveUp(func(call CallInfo, err *RetryError) {
+			assert.Equal(t, 2, call.Attempts)
+			gaveUp = append(gaveUp, err)
+		}),
+		WithOnError(func(call CallInfo, err error) {
+			errs = append(errs, err)
+		}),
+	)
+
+	_, _, err := client.Get(ts.URL)
+	assert.Error(t, err)
+	if assert.Len(t, gaveUp, 1) {
+		assert.Equal(t, StopRetriesExhausted, gaveUp[0].Reason)
+	}
+
+	// non-retryable errors are reported to OnError only
+	_, _, err = client.Get(ts.URL + "/missing")
+	assert.Error(t, err)
+	assert.Len(t, gaveUp, 1)
+	assert.Len(t, errs, 2)
+}
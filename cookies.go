@@ -0,0 +1,279 @@
+package httpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// CookieJar is an in-memory cookie jar with public suffix handling that keeps
+// track of every stored cookie, so they can be inspected, cleared per domain
+// and optionally persisted to a file.
+type CookieJar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	entries map[string]storedCookie
+	path    string
+}
+
+type storedCookie struct {
+	URL      string        `json:"url"`
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Domain   string        `json:"domain,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Expires  time.Time     `json:"expires,omitempty"`
+	Secure   bool          `json:"secure,omitempty"`
+	HttpOnly bool          `json:"http_only,omitempty"`
+	SameSite http.SameSite `json:"same_site,omitempty"`
+}
+
+func NewCookieJar() *CookieJar {
+	return &CookieJar{
+		jar:     newPublicSuffixJar(),
+		entries: make(map[string]storedCookie),
+	}
+}
+
+// NewFileCookieJar returns a jar persisted at path. Cookies already saved at
+// path are loaded; a missing file is not an error.
+func NewFileCookieJar(path string) (*CookieJar, error) {
+	j := NewCookieJar()
+	j.path = path
+	if err := j.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return j, nil
+}
+
+func newPublicSuffixJar() *cookiejar.Jar {
+	// cookiejar.New only fails on invalid options.
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
+
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.jar.SetCookies(u, cookies)
+	now := time.Now()
+	for _, cookie := range cookies {
+		entry := newStoredCookie(u, cookie, now)
+		if !acceptsDomain(u, entry.Domain) {
+			continue
+		}
+		key := entry.key()
+		if cookie.MaxAge < 0 || (!entry.Expires.IsZero() && !entry.Expires.After(now)) {
+			delete(j.entries, key)
+			continue
+		}
+		j.entries[key] = entry
+	}
+}
+
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// DomainCookies returns every stored cookie whose domain matches domain or
+// one of its subdomains.
+func (j *CookieJar) DomainCookies(domain string) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	var cookies []*http.Cookie
+	for _, entry := range j.entries {
+		if entry.expired(now) || !domainMatch(entry.domain(), domain) {
+			continue
+		}
+		cookie := entry.cookie()
+		cookie.Domain = entry.domain()
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+// Clear removes every cookie whose domain matches domain or one of its
+// subdomains. An empty domain clears the whole jar.
+func (j *CookieJar) Clear(domain string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for key, entry := range j.entries {
+		if domain == "" || domainMatch(entry.domain(), domain) {
+			delete(j.entries, key)
+		}
+	}
+	j.rebuild()
+}
+
+// Save writes the cookies to the file the jar was created with.
+func (j *CookieJar) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.path == "" {
+		return errors.New("cookie jar has no file path")
+	}
+
+	now := time.Now()
+	entries := make([]storedCookie, 0, len(j.entries))
+	for _, entry := range j.entries {
+		if !entry.expired(now) {
+			entries = append(entries, entry)
+		}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// Load replaces the jar content with the cookies saved in the jar file.
+func (j *CookieJar) Load() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.path == "" {
+		return errors.New("cookie jar has no file path")
+	}
+
+	data, err := os.ReadFile(j.path)
+	if err != nil {
+		return err
+	}
+	var entries []storedCookie
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	j.entries = make(map[string]storedCookie, len(entries))
+	now := time.Now()
+	for _, entry := range entries {
+		if !entry.expired(now) {
+			j.entries[entry.key()] = entry
+		}
+	}
+	j.rebuild()
+	return nil
+}
+
+func (j *CookieJar) rebuild() {
+	j.jar = newPublicSuffixJar()
+	for _, entry := range j.entries {
+		u, err := url.Parse(entry.URL)
+		if err != nil {
+			continue
+		}
+		j.jar.SetCookies(u, []*http.Cookie{entry.cookie()})
+	}
+}
+
+func newStoredCookie(u *url.URL, cookie *http.Cookie, now time.Time) storedCookie {
+	entry := storedCookie{
+		URL:      (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String(),
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   strings.TrimPrefix(strings.ToLower(cookie.Domain), "."),
+		Path:     cookie.Path,
+		Expires:  cookie.Expires,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		SameSite: cookie.SameSite,
+	}
+	if cookie.MaxAge > 0 {
+		entry.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	return entry
+}
+
+func (e storedCookie) key() string {
+	return e.domain() + ";" + e.Path + ";" + e.Name
+}
+
+// domain returns the cookie domain, falling back to the host that set it
+// for host-only cookies.
+func (e storedCookie) domain() string {
+	if e.Domain != "" {
+		return e.Domain
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func (e storedCookie) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !e.Expires.After(now)
+}
+
+func (e storedCookie) cookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Domain:   e.Domain,
+		Path:     e.Path,
+		Expires:  e.Expires,
+		Secure:   e.Secure,
+		HttpOnly: e.HttpOnly,
+		SameSite: e.SameSite,
+	}
+}
+
+// acceptsDomain mirrors the checks cookiejar applies to the Domain attribute,
+// so rejected cookies are not tracked.
+func acceptsDomain(u *url.URL, domain string) bool {
+	if domain == "" {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	if !domainMatch(host, domain) {
+		return false
+	}
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix != domain || host == domain
+}
+
+func domainMatch(cookieDomain, domain string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	return cookieDomain == domain || strings.HasSuffix(cookieDomain, "."+domain)
+}
+
+// Cookies returns the cookies the jar would send to addrs.
+func (c *HttpClient) Cookies(addrs string) []*http.Cookie {
+	if c.client.Jar == nil {
+		return nil
+	}
+	u, err := url.Parse(addrs)
+	if err != nil {
+		return nil
+	}
+	return c.client.Jar.Cookies(u)
+}
+
+// ClearCookies removes the cookies of domain and its subdomains. It only works
+// with jars created by NewCookieJar or NewFileCookieJar.
+func (c *HttpClient) ClearCookies(domain string) {
+	if jar, ok := c.client.Jar.(*CookieJar); ok {
+		jar.Clear(domain)
+	}
+}
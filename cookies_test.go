@@ -0,0 +1,103 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSessionServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			return
+		}
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(cookie.Value))
+	}))
+}
+
+func TestHttpClient_DefaultCookieJarKeepsSession(t *testing.T) {
+	ts := newSessionServer()
+	defer ts.Close()
+
+	client := NewHttpClient()
+	_, _, err := client.Get(ts.URL + "/login")
+	assert.NoError(t, err)
+
+	_, body, err := client.Get(ts.URL + "/me")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(body))
+	assert.Len(t, client.Cookies(ts.URL), 1)
+
+	client.ClearCookies("127.0.0.1")
+	assert.Empty(t, client.Cookies(ts.URL))
+	_, _, err = client.Get(ts.URL + "/me")
+	assert.Error(t, err)
+}
+
+func TestHttpClient_WithoutCookieJar(t *testing.T) {
+	ts := newSessionServer()
+	defer ts.Close()
+
+	client := NewHttpClient(WithCookieJar(nil))
+	_, _, err := client.Get(ts.URL + "/login")
+	assert.NoError(t, err)
+
+	_, _, err = client.Get(ts.URL + "/me")
+	assert.Error(t, err)
+	assert.Nil(t, client.Cookies(ts.URL))
+}
+
+func TestFileCookieJar_SaveAndLoad(t *testing.T) {
+	ts := newSessionServer()
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := NewFileCookieJar(path)
+	assert.NoError(t, err)
+
+	client := NewHttpClient(WithCookieJar(jar))
+	_, _, err = client.Get(ts.URL + "/login")
+	assert.NoError(t, err)
+	assert.NoError(t, jar.Save())
+
+	restored, err := NewFileCookieJar(path)
+	assert.NoError(t, err)
+	assert.Len(t, restored.DomainCookies("127.0.0.1"), 1)
+
+	client = NewHttpClient(WithCookieJar(restored))
+	_, body, err := client.Get(ts.URL + "/me")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(body))
+}
+
+func TestCookieJar_ClearPerDomain(t *testing.T) {
+	jar := NewCookieJar()
+	api, _ := url.Parse("https://api.example.com/")
+	other, _ := url.Parse("https://other.org/")
+
+	jar.SetCookies(api, []*http.Cookie{{Name: "a", Value: "1", Domain: "example.com"}})
+	jar.SetCookies(other, []*http.Cookie{{Name: "b", Value: "2"}})
+	// public suffixes are rejected and not tracked
+	jar.SetCookies(api, []*http.Cookie{{Name: "c", Value: "3", Domain: "com"}})
+
+	assert.Len(t, jar.DomainCookies("example.com"), 1)
+	assert.Empty(t, jar.DomainCookies("com.br"))
+
+	jar.Clear("example.com")
+	assert.Empty(t, jar.Cookies(api))
+	assert.Len(t, jar.Cookies(other), 1)
+
+	jar.SetCookies(other, []*http.Cookie{{Name: "b", MaxAge: -1}})
+	assert.Empty(t, jar.Cookies(other))
+	assert.Empty(t, jar.DomainCookies("other.org"))
+}
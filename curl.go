@@ -0,0 +1,130 @@
+package httpc
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// CurlCommand returns a curl command line sending req, with the method, URL,
+// headers, basic auth and body. Secrets are masked when redactor is not nil.
+func CurlCommand(req *http.Request, redactor *Redactor) (string, error) {
+	body, err := requestPayload(req)
+	if err != nil {
+		return "", fmt.Errorf("reading request body failed: %w", err)
+	}
+
+	header := req.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	addrs := req.URL.String()
+	if redactor != nil {
+		header = redactor.Header(header)
+		addrs = redactor.URL(addrs)
+		body = redactor.Body(req.Header.Get("Content-Type"), body)
+	}
+
+	args := []string{"curl"}
+	switch {
+	case req.Method == http.MethodHead:
+		args = append(args, "--head")
+	case req.Method != http.MethodGet || len(body) > 0:
+		args = append(args, "-X", req.Method)
+	}
+	args = append(args, shellQuote(addrs))
+
+	if username, password, ok := req.BasicAuth(); ok {
+		if redactor != nil {
+			password = RedactedValue
+		}
+		header.Del("Authorization")
+		args = append(args, "--user", shellQuote(username+":"+password))
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			args = append(args, "-H", shellQuote(name+": "+value))
+		}
+	}
+
+	if len(body) > 0 {
+		// --data-raw sends the body as is, --data-binary would read a file
+		// for a body starting with '@'
+		args = append(args, "--data-raw", shellQuote(string(body)))
+	}
+	return strings.Join(args, " "), nil
+}
+
+// CurlHook returns a callback for WithOnAttemptStart that writes the curl
+// command of every attempt to w, one per line.
+func CurlHook(w io.Writer, redactor *Redactor) AttemptStartFunc {
+	return func(info AttemptInfo) {
+		command, err := CurlCommand(info.Request, redactor)
+		if err != nil {
+			fmt.Fprintf(w, "# attempt %d: %v\n", info.Attempt, err)
+			return
+		}
+		fmt.Fprintln(w, command)
+	}
+}
+
+// shellQuote quotes s for POSIX shells. Strings with control characters or
+// invalid UTF-8 use ANSI-C quoting, $'...', which bash and zsh support.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, isShellUnsafe) < 0 {
+		return s
+	}
+	if utf8.ValidString(s) && strings.IndexFunc(s, isControl) < 0 {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+
+	var b strings.Builder
+	b.WriteString("$'")
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case r == '\'' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case isControl(r):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	b.WriteString("'")
+	return b.String()
+}
+
+func isShellUnsafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("-_./:@%+=,", r)
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
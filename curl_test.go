@@ -0,0 +1,107 @@
+package httpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurlCommand(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/users?q=a b&token=s3cr3t", strings.NewReader(`{"name":"O'Brien","password":"p"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("X-Tag", "a")
+	req.Header.Add("X-Tag", "b")
+	req.SetBasicAuth("ana", "s3cr3t")
+
+	command, err := CurlCommand(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `curl -X POST 'https://api.example.com/users?q=a b&token=s3cr3t' --user ana:s3cr3t`+
+		` -H 'Content-Type: application/json' -H 'X-Tag: a' -H 'X-Tag: b'`+
+		` --data-raw '{"name":"O'\''Brien","password":"p"}'`, command)
+
+	command, err = CurlCommand(req, &Redactor{QueryParams: []string{"token"}, JSONFields: []string{"password"}})
+	assert.NoError(t, err)
+	assert.NotContains(t, command, "s3cr3t")
+	assert.Contains(t, command, `--user 'ana:[REDACTED]'`)
+	assert.Contains(t, command, `"password":"[REDACTED]"`)
+}
+
+func TestCurlCommand_RedactsForm(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/login", strings.NewReader("user=ana&password=hunter2"))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", formContentType)
+
+	command, err := CurlCommand(req, &Redactor{JSONFields: []string{"password"}})
+	assert.NoError(t, err)
+	assert.Contains(t, command, `--data-raw 'user=ana&password=[REDACTED]'`)
+	assert.NotContains(t, command, "hunter2")
+}
+
+func TestCurlCommand_LiteralAtBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("@/etc/passwd"))
+	assert.NoError(t, err)
+
+	// curl reads a file for --data-binary @path, but not for --data-raw
+	command, err := CurlCommand(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "curl -X POST http://example.com/ --data-raw @/etc/passwd", command)
+
+	parsed, err := ParseCurl(command)
+	assert.NoError(t, err)
+	assert.Equal(t, "@/etc/passwd", string(parsed.Body))
+}
+
+func TestCurlCommand_Methods(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	command, err := CurlCommand(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "curl http://example.com/", command)
+
+	req, _ = http.NewRequest(http.MethodHead, "http://example.com/", nil)
+	req.Host = "internal.example.com"
+	command, err = CurlCommand(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "curl --head http://example.com/ -H 'Host: internal.example.com'", command)
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "plain-value_1.0", shellQuote("plain-value_1.0"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, `'it'\''s $HOME'`, shellQuote("it's $HOME"))
+	assert.Equal(t, `$'line\nnext \'q\' \x00\xff'`, shellQuote("line\nnext 'q' \x00\xff"))
+}
+
+func TestCurlHook(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	client := NewHttpClient(WithOnAttemptStart(CurlHook(&out, &Redactor{})))
+	client.SetFormValue(http.MethodPost, "user", "ana")
+	client.SetBasicAuth(http.MethodPost, "ana", "s3cr3t")
+
+	_, _, err := client.Post(ts.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "curl -X POST "+ts.URL+" --user 'ana:[REDACTED]'"+
+		" -H 'Content-Type: application/x-www-form-urlencoded' --data-raw user=ana\n", out.String())
+}
+
+func TestCurlCommand_Runs(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	// the shell must hand the quoted arguments back unchanged
+	for _, s := range []string{"it's", `a"b\c $x`, ""} {
+		out, err := exec.Command(sh, "-c", "printf %s "+shellQuote(s)).Output()
+		assert.NoError(t, err)
+		assert.Equal(t, s, string(out))
+	}
+}
@@ -0,0 +1,509 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CurlRequest is a request parsed from a curl command. Insecure (-k) and
+// Resolve (--resolve) configure the transport, create the client with
+// ClientOptions to honour them.
+type CurlRequest struct {
+	Request
+	Insecure bool
+	// Resolve maps "host:port" to the addresses it resolves to.
+	Resolve map[string][]string
+	// Compressed is informational, the client always asks for and decodes
+	// gzip responses unless an Accept-Encoding header is set.
+	Compressed bool
+}
+
+// ClientOptions returns the client options needed by the transport flags.
+func (r *CurlRequest) ClientOptions() []HttpClientOptions {
+	var opts []HttpClientOptions
+	if r.Insecure {
+		opts = append(opts, WithInsecureSkipVerify(true))
+	}
+	for host, addrs := range r.Resolve {
+		opts = append(opts, WithHostOverride(host, addrs...))
+	}
+	return opts
+}
+
+// curlShortFlags maps the supported short flags to their long form.
+var curlShortFlags = map[string]string{
+	"-X": "--request",
+	"-H": "--header",
+	"-d": "--data",
+	"-F": "--form",
+	"-u": "--user",
+	"-A": "--user-agent",
+	"-e": "--referer",
+	"-b": "--cookie",
+	"-G": "--get",
+	"-I": "--head",
+	"-k": "--insecure",
+	"-s": "--silent",
+	"-S": "--show-error",
+	"-L": "--location",
+	"-i": "--include",
+	"-v": "--verbose",
+}
+
+// curlFlags are the supported long flags, true for those taking a value.
+// Flags that only change the output of curl are accepted and ignored.
+var curlFlags = map[string]bool{
+	"--request":        true,
+	"--header":         true,
+	"--data":           true,
+	"--data-ascii":     true,
+	"--data-raw":       true,
+	"--data-binary":    true,
+	"--data-urlencode": true,
+	"--form":           true,
+	"--user":           true,
+	"--user-agent":     true,
+	"--referer":        true,
+	"--cookie":         true,
+	"--url":            true,
+	"--resolve":        true,
+	"--get":            false,
+	"--head":           false,
+	"--insecure":       false,
+	"--compressed":     false,
+	"--silent":         false,
+	"--show-error":     false,
+	"--location":       false,
+	"--include":        false,
+	"--verbose":        false,
+}
+
+// ParseCurl parses a curl command line into a request. It supports -X, -H,
+// -d, --data-raw, --data-binary, --data-urlencode, -F, -u, -G, -I, -A, -e,
+// -b, --url, --compressed, -k and --resolve, and fails on other flags.
+// "@file" data and form values are read from disk.
+func ParseCurl(command string) (*CurlRequest, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, fmt.Errorf("parsing curl command failed: %w", err)
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("parsing curl command failed: it must start with curl")
+	}
+
+	p := &curlParser{header: http.Header{}}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if err := p.setURL(arg); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for _, flag := range splitCurlFlags(arg) {
+			long, ok := flag, strings.HasPrefix(flag, "--")
+			if !ok {
+				long, ok = curlShortFlags[flag[:2]]
+			}
+			takesValue, supported := curlFlags[long]
+			if !ok || !supported {
+				return nil, fmt.Errorf("unsupported curl flag %s", flag)
+			}
+
+			var value string
+			if takesValue {
+				switch {
+				case !strings.HasPrefix(flag, "--") && len(flag) > 2:
+					value = flag[2:]
+				case i+1 < len(args):
+					i++
+					value = args[i]
+				default:
+					return nil, fmt.Errorf("curl flag %s needs a value", flag)
+				}
+			}
+			if err := p.apply(long, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p.request()
+}
+
+// splitCurlFlags splits combined short flags, -sSL is -s -S -L, and keeps
+// the value attached to the last one, as in -sXPOST.
+func splitCurlFlags(arg string) []string {
+	if strings.HasPrefix(arg, "--") {
+		return []string{arg}
+	}
+	var flags []string
+	for i := 1; i < len(arg); i++ {
+		flag := "-" + arg[i:i+1]
+		if long, ok := curlShortFlags[flag]; ok && curlFlags[long] {
+			return append(flags, "-"+arg[i:])
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+type curlParser struct {
+	method     string
+	addrs      string
+	header     http.Header
+	data       []string
+	form       []string
+	get        bool
+	head       bool
+	insecure   bool
+	compressed bool
+	resolve    map[string][]string
+}
+
+func (p *curlParser) setURL(addrs string) error {
+	if p.addrs != "" {
+		return fmt.Errorf("curl command has more than one URL: %s and %s", p.addrs, addrs)
+	}
+	p.addrs = addrs
+	return nil
+}
+
+func (p *curlParser) apply(flag, value string) error {
+	switch flag {
+	case "--request":
+		p.method = strings.ToUpper(value)
+	case "--header":
+		name, headerValue, ok := strings.Cut(value, ":")
+		if !ok {
+			// "Name;" sends an empty header
+			if name, ok = strings.CutSuffix(value, ";"); !ok {
+				return fmt.Errorf("invalid curl header %q", value)
+			}
+		}
+		name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		p.header.Add(name, strings.TrimSpace(headerValue))
+	case "--data", "--data-ascii", "--data-binary":
+		data, err := readCurlData(value)
+		if err != nil {
+			return err
+		}
+		if flag != "--data-binary" {
+			data = strings.NewReplacer("\r", "", "\n", "").Replace(data)
+		}
+		p.data = append(p.data, data)
+	case "--data-raw":
+		p.data = append(p.data, value)
+	case "--data-urlencode":
+		data, err := encodeCurlData(value)
+		if err != nil {
+			return err
+		}
+		p.data = append(p.data, data)
+	case "--form":
+		p.form = append(p.form, value)
+	case "--user":
+		p.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+	case "--user-agent":
+		p.header.Set("User-Agent", value)
+	case "--referer":
+		p.header.Set("Referer", value)
+	case "--cookie":
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("unsupported curl cookie file %q, pass the cookies as name=value", value)
+		}
+		p.header.Add("Cookie", value)
+	case "--url":
+		return p.setURL(value)
+	case "--resolve":
+		return p.addResolve(value)
+	case "--get":
+		p.get = true
+	case "--head":
+		p.head = true
+	case "--insecure":
+		p.insecure = true
+	case "--compressed":
+		p.compressed = true
+	}
+	return nil
+}
+
+func (p *curlParser) addResolve(value string) error {
+	// host:port:addr[,addr...], IPv6 addresses are bracketed
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return fmt.Errorf("invalid curl --resolve %q, expected host:port:addr", value)
+	}
+	if _, err := strconv.Atoi(parts[1]); err != nil {
+		return fmt.Errorf("invalid curl --resolve port in %q", value)
+	}
+	if p.resolve == nil {
+		p.resolve = make(map[string][]string)
+	}
+	host := parts[0] + ":" + parts[1]
+	for _, addr := range strings.Split(parts[2], ",") {
+		p.resolve[host] = append(p.resolve[host], strings.Trim(addr, "[]"))
+	}
+	return nil
+}
+
+func (p *curlParser) request() (*CurlRequest, error) {
+	if p.addrs == "" {
+		return nil, errors.New("curl command has no URL")
+	}
+	if len(p.data) > 0 && len(p.form) > 0 {
+		return nil, errors.New("curl command mixes -d and -F")
+	}
+
+	addrs := p.addrs
+	if !strings.Contains(addrs, "://") {
+		addrs = "http://" + addrs
+	}
+	if _, err := url.Parse(addrs); err != nil {
+		return nil, fmt.Errorf("invalid curl URL: %w", err)
+	}
+
+	req := &CurlRequest{
+		Request:    Request{URL: addrs, Header: p.header},
+		Insecure:   p.insecure,
+		Resolve:    p.resolve,
+		Compressed: p.compressed,
+	}
+
+	method := http.MethodGet
+	switch {
+	case p.head:
+		method = http.MethodHead
+	case p.get:
+		if len(p.data) > 0 {
+			separator := "?"
+			if strings.Contains(addrs, "?") {
+				separator = "&"
+			}
+			req.URL = addrs + separator + strings.Join(p.data, "&")
+		}
+	case len(p.data) > 0:
+		method = http.MethodPost
+		req.Body = []byte(strings.Join(p.data, "&"))
+		if p.header.Get("Content-Type") == "" {
+			p.header.Set("Content-Type", formContentType)
+		}
+	case len(p.form) > 0:
+		method = http.MethodPost
+		body, contentType, err := encodeCurlForm(p.form)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+		p.header.Set("Content-Type", contentType)
+	}
+	if p.method != "" {
+		method = p.method
+	}
+	req.Method = method
+	return req, nil
+}
+
+func readCurlData(value string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	data, err := os.ReadFile(value[1:])
+	if err != nil {
+		return "", fmt.Errorf("reading curl data file failed: %w", err)
+	}
+	return string(data), nil
+}
+
+// encodeCurlData implements the --data-urlencode forms: content, =content,
+// name=content, @file and name@file.
+func encodeCurlData(value string) (string, error) {
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name, content := value[:i], value[i+1:]
+		if value[i] == '@' {
+			data, err := readCurlData(value[i:])
+			if err != nil {
+				return "", err
+			}
+			content = data
+		}
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	}
+	return url.QueryEscape(value), nil
+}
+
+// encodeCurlForm builds a multipart body from -F values: name=value,
+// name=@file and name=<file, with optional ;type= and ;filename= options.
+func encodeCurlForm(fields []string) ([]byte, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, field := range fields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, "", fmt.Errorf("invalid curl form field %q, expected name=value", field)
+		}
+
+		if !strings.HasPrefix(value, "@") && !strings.HasPrefix(value, "<") {
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+
+		parts := strings.Split(value[1:], ";")
+		path := parts[0]
+		filename := filepath.Base(path)
+		contentType := "application/octet-stream"
+		for _, option := range parts[1:] {
+			key, optionValue, _ := strings.Cut(option, "=")
+			switch strings.TrimSpace(key) {
+			case "type":
+				contentType = optionValue
+			case "filename":
+				filename = optionValue
+			default:
+				return nil, "", fmt.Errorf("unsupported curl form option %q", option)
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("reading curl form file failed: %w", err)
+		}
+
+		header := make(textproto.MIMEHeader)
+		if value[0] == '@' {
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, name, filename))
+			header.Set("Content-Type", contentType)
+		} else {
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, name))
+		}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		part.Write(data)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// splitShellWords splits a command line like a POSIX shell, with single,
+// double and $'...' quotes, backslash escapes and line continuations.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case ch == '\\':
+			if i+1 >= len(s) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			if s[i] != '\n' {
+				word.WriteByte(s[i])
+				inWord = true
+			}
+		case ch == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case ch == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		case ch == '$' && i+1 < len(s) && s[i+1] == '\'':
+			n, err := readANSIQuote(s[i+2:], &word)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+			inWord = true
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// readANSIQuote decodes the body of a $'...' quote into word and returns the
+// bytes consumed, including the closing quote.
+func readANSIQuote(s string, word *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			return i, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return 0, errors.New("unterminated $' quote")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				word.WriteByte('\n')
+			case 'r':
+				word.WriteByte('\r')
+			case 't':
+				word.WriteByte('\t')
+			case 'x':
+				if i+2 >= len(s) {
+					return 0, errors.New("invalid \\x escape")
+				}
+				b, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+				if err != nil {
+					return 0, errors.New("invalid \\x escape")
+				}
+				word.WriteByte(byte(b))
+				i += 2
+			default:
+				word.WriteByte(s[i])
+			}
+		default:
+			word.WriteByte(s[i])
+		}
+	}
+	return 0, errors.New("unterminated $' quote")
+}
@@ -0,0 +1,134 @@
+package httpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurl(t *testing.T) {
+	req, err := ParseCurl(`curl -sSL -XPUT 'https://api.example.com/users/1' \
+  -H 'Content-Type: application/json' \
+  -H "X-Trace: a \"b\"" \
+  -u ana:s3cr3t \
+  --data-raw '{"name":"ana"}' --compressed -k \
+  --resolve api.example.com:443:127.0.0.1`)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Equal(t, "https://api.example.com/users/1", req.URL)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, `a "b"`, req.Header.Get("X-Trace"))
+	assert.Equal(t, "Basic YW5hOnMzY3IzdA==", req.Header.Get("Authorization"))
+	assert.Equal(t, `{"name":"ana"}`, string(req.Body))
+	assert.True(t, req.Compressed)
+	assert.True(t, req.Insecure)
+	assert.Equal(t, map[string][]string{"api.example.com:443": {"127.0.0.1"}}, req.Resolve)
+	assert.Len(t, req.ClientOptions(), 2)
+}
+
+func TestParseCurl_Data(t *testing.T) {
+	req, err := ParseCurl(`curl example.com/search -d a=1 --data-urlencode 'q=go http' --data-urlencode =x&y`)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "http://example.com/search", req.URL)
+	assert.Equal(t, formContentType, req.Header.Get("Content-Type"))
+	assert.Equal(t, "a=1&q=go+http&x%26y", string(req.Body))
+
+	req, err = ParseCurl(`curl -G https://example.com/search?lang=en -d a=1 --data-urlencode 'q=go http'`)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, req.Method)
+	assert.Equal(t, "https://example.com/search?lang=en&a=1&q=go+http", req.URL)
+	assert.Empty(t, req.Body)
+
+	req, err = ParseCurl(`curl -I https://example.com`)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodHead, req.Method)
+}
+
+func TestParseCurl_Form(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	require.NoError(t, os.WriteFile(path, []byte("a,b\n1,2\n"), 0o600))
+
+	req, err := ParseCurl(`curl https://example.com/upload -F name=ana -F 'file=@` + path + `;type=text/csv'`)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(req.Body)))
+	httpReq.Header = req.Header
+	require.NoError(t, httpReq.ParseMultipartForm(1<<20))
+	assert.Equal(t, "ana", httpReq.FormValue("name"))
+	file, header, err := httpReq.FormFile("file")
+	require.NoError(t, err)
+	content, _ := io.ReadAll(file)
+	assert.Equal(t, "a,b\n1,2\n", string(content))
+	assert.Equal(t, "report.csv", header.Filename)
+	assert.Equal(t, "text/csv", header.Header.Get("Content-Type"))
+}
+
+func TestParseCurl_Errors(t *testing.T) {
+	for command, message := range map[string]string{
+		`wget https://example.com`:             "must start with curl",
+		`curl`:                                 "no URL",
+		`curl https://example.com -o out`:      "unsupported curl flag -o",
+		`curl https://example.com --http2`:     "unsupported curl flag --http2",
+		`curl https://example.com -sZ`:         "unsupported curl flag -Z",
+		`curl https://example.com -H`:          "curl flag -H needs a value",
+		`curl 'https://example.com`:            "unterminated single quote",
+		`curl a.com b.com`:                     "more than one URL",
+		`curl a.com -d a=1 -F b=2`:             "mixes -d and -F",
+		`curl a.com --resolve a.com:x:1.2.3.4`: "invalid curl --resolve port",
+	} {
+		_, err := ParseCurl(command)
+		if assert.Error(t, err, command) {
+			assert.Contains(t, err.Error(), message, command)
+		}
+	}
+}
+
+func TestParseCurl_RoundTrip(t *testing.T) {
+	httpReq, err := http.NewRequest(http.MethodPost, "https://example.com/x?a=b", strings.NewReader("line\nnext 'q'"))
+	require.NoError(t, err)
+	httpReq.Header.Set("Content-Type", "text/plain")
+	httpReq.SetBasicAuth("ana", "p w")
+
+	command, err := CurlCommand(httpReq, nil)
+	require.NoError(t, err)
+	req, err := ParseCurl(command)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "https://example.com/x?a=b", req.URL)
+	assert.Equal(t, "line\nnext 'q'", string(req.Body))
+	assert.Equal(t, httpReq.Header.Get("Authorization"), req.Header.Get("Authorization"))
+}
+
+func TestHttpClient_DoCurl(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Host, "api.example.com:"))
+		assert.Equal(t, "v", r.Header.Get("X-Shared"))
+		assert.Equal(t, "yes", r.Header.Get("X-Curl"))
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	req, err := ParseCurl(`curl -k --resolve api.example.com:` + port + `:127.0.0.1 https://api.example.com:` + port + `/echo -H 'X-Curl: yes' -d hello`)
+	require.NoError(t, err)
+
+	client := NewHttpClient(req.ClientOptions()...)
+	client.SetHeader(http.MethodPost, "X-Shared", "v")
+	_, body, err := client.Do(context.Background(), &req.Request)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+}
@@ -0,0 +1,153 @@
+package httpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DebugEnv is the environment variable that, set to a true value such as
+// "1", dumps the attempts of every client to stderr unless WithDebug is used.
+const DebugEnv = "HTTPC_DEBUG"
+
+const defaultDebugBodyLimit = 4096
+
+// WithDebug dumps the request and response of every attempt to w, headers
+// and bodies included. Secrets are masked by the client redactor, see
+// WithRedactor, and binary bodies are elided.
+func WithDebug(w io.Writer) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.Debug = w
+	}
+}
+
+// WithDebugBodyLimit sets the number of body bytes dumped, 4096 by default.
+func WithDebugBodyLimit(limit int) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.DebugBodyLimit = limit
+	}
+}
+
+func debugFromEnv() io.Writer {
+	if enabled, err := strconv.ParseBool(os.Getenv(DebugEnv)); err == nil && enabled {
+		return os.Stderr
+	}
+	return nil
+}
+
+func (c *HttpClient) debugAttempt(info AttemptInfo, resp *http.Response, err error, duration time.Duration) {
+	if c.params == nil || c.params.Debug == nil {
+		return
+	}
+	redactor := c.redactor()
+	limit := c.params.DebugBodyLimit
+	req := info.Request
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--> %s %s (attempt %d)\n", req.Method, redactor.URL(info.URL), info.Attempt)
+	fmt.Fprintf(&b, "%s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+	fmt.Fprintf(&b, "Host: %s\n", req.Host)
+	writeDebugHeader(&b, redactor.Header(req.Header))
+	if payload, err := requestPayload(req); err == nil && len(payload) > 0 {
+		writeDebugBody(&b, redactor, req.Header, payload, len(payload), limit)
+	}
+
+	if err != nil {
+		fmt.Fprintf(&b, "<-- error (%s): %v\n\n", duration, err)
+	} else {
+		fmt.Fprintf(&b, "<-- %s (%s)\n", resp.Status, duration)
+		fmt.Fprintf(&b, "%s %s\n", resp.Proto, resp.Status)
+		writeDebugHeader(&b, redactor.Header(resp.Header))
+		if limit > 0 {
+			peek, _ := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+			resp.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(peek), resp.Body), Closer: resp.Body}
+			if len(peek) > 0 {
+				size := int(resp.ContentLength)
+				if size < len(peek) {
+					size = len(peek)
+				}
+				writeDebugBody(&b, redactor, resp.Header, peek, size, limit)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	c.debugMu.Lock()
+	defer c.debugMu.Unlock()
+	c.params.Debug.Write(b.Bytes())
+}
+
+func writeDebugHeader(b *bytes.Buffer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(b, "%s: %s\n", name, value)
+		}
+	}
+}
+
+// writeDebugBody writes up to limit bytes of body, size is its full size when
+// known.
+func writeDebugBody(b *bytes.Buffer, redactor *Redactor, header http.Header, body []byte, size, limit int) {
+	b.WriteString("\n")
+	if limit <= 0 {
+		return
+	}
+	if isBinaryBody(header.Get("Content-Type"), body) {
+		fmt.Fprintf(b, "[binary body, %d bytes elided]\n", size)
+		return
+	}
+	body = redactor.Body(header.Get("Content-Type"), body)
+	if len(body) > limit {
+		b.Write(body[:limit])
+		b.WriteString("\n[body truncated]\n")
+		return
+	}
+	b.Write(body)
+	b.WriteString("\n")
+}
+
+func isBinaryBody(contentType string, body []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case strings.HasPrefix(mediaType, "text/"),
+			strings.HasSuffix(mediaType, "json"),
+			strings.HasSuffix(mediaType, "xml"),
+			mediaType == "application/x-www-form-urlencoded",
+			mediaType == "application/javascript":
+			return false
+		case strings.HasPrefix(mediaType, "image/"),
+			strings.HasPrefix(mediaType, "audio/"),
+			strings.HasPrefix(mediaType, "video/"),
+			mediaType == "application/octet-stream",
+			mediaType == "application/zip",
+			mediaType == "application/gzip",
+			mediaType == "application/pdf":
+			return true
+		}
+	}
+	// a body cut at the limit may end in the middle of a rune
+	text := body
+	for i := 0; i < utf8.UTFMax-1 && len(text) > 0 && !utf8.Valid(text); i++ {
+		text = text[:len(text)-1]
+	}
+	return !utf8.Valid(text) || bytes.IndexByte(text, 0) >= 0
+}
+
+// peekedBody restores the bytes read from a body for the dump.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
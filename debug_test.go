@@ -0,0 +1,122 @@
+package httpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_Debug(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0, 1, 2})
+		case "/long":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("a", 100)))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=abc")
+			w.Write([]byte(`{"token":"t0k3n","ok":true}`))
+		}
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	client := NewHttpClient(
+		WithDebug(&out),
+		WithDebugBodyLimit(32),
+		WithRedactor(Redactor{JSONFields: []string{"password", "token"}}),
+	)
+	client.SetBasicAuth(http.MethodPost, "ana", "s3cr3t")
+
+	_, body, err := client.Post(ts.URL+"/login", []byte(`{"user":"ana","password":"s3cr3t"}`))
+	assert.NoError(t, err)
+	// the dumped body is still returned in full
+	assert.JSONEq(t, `{"token":"t0k3n","ok":true}`, string(body))
+
+	dump := out.String()
+	assert.Contains(t, dump, "--> POST "+ts.URL+"/login (attempt 1)\nPOST /login HTTP/1.1\n")
+	assert.Contains(t, dump, "Authorization: [REDACTED]\n")
+	assert.Contains(t, dump, `"password":"[REDACTED]"`)
+	assert.Contains(t, dump, "HTTP/1.1 200 OK\n")
+	assert.Contains(t, dump, "Set-Cookie: [REDACTED]\n")
+	assert.Contains(t, dump, `"token":"[REDACTED]"`)
+	assert.NotContains(t, dump, "s3cr3t")
+	assert.NotContains(t, dump, "t0k3n")
+
+	out.Reset()
+	_, body, err = client.Get(ts.URL + "/image")
+	assert.NoError(t, err)
+	assert.Len(t, body, 7)
+	assert.Contains(t, out.String(), "[binary body, 7 bytes elided]")
+
+	out.Reset()
+	_, body, err = client.Get(ts.URL + "/long")
+	assert.NoError(t, err)
+	assert.Len(t, body, 100)
+	assert.Contains(t, out.String(), strings.Repeat("a", 32)+"\n[body truncated]\n")
+}
+
+func TestHttpClient_DebugRedactsForms(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	client := NewHttpClient(WithDebug(&out), WithRedactor(Redactor{JSONFields: []string{"password"}}))
+	_, _, err := client.PostForm(ts.URL, url.Values{"user": {"ana"}, "password": {"hunter2"}})
+	assert.NoError(t, err)
+
+	client.SetFormValue(http.MethodPut, "password", "hunter2")
+	_, _, err = client.Put(ts.URL, nil)
+	assert.NoError(t, err)
+
+	dump := out.String()
+	assert.Contains(t, dump, "password=[REDACTED]&user=ana\n")
+	assert.NotContains(t, dump, "hunter2")
+}
+
+// mapWriter is not comparable, so it cannot be a map key.
+type mapWriter struct {
+	lines map[int]string
+	n     *int
+}
+
+func (w mapWriter) Write(p []byte) (int, error) {
+	*w.n++
+	w.lines[*w.n] = string(p)
+	return len(p), nil
+}
+
+func TestHttpClient_DebugUncomparableWriter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	w := mapWriter{lines: map[int]string{}, n: new(int)}
+	client := NewHttpClient(WithDebug(w))
+	assert.NotPanics(t, func() {
+		_, _, err := client.Get(ts.URL)
+		assert.NoError(t, err)
+	})
+	assert.Contains(t, w.lines[1], "--> GET "+ts.URL)
+}
+
+func TestDebugFromEnv(t *testing.T) {
+	t.Setenv(DebugEnv, "1")
+	assert.Equal(t, os.Stderr, newHttpClientParams().Debug)
+	assert.Nil(t, newHttpClientParams(WithDebug(nil)).Debug)
+
+	t.Setenv(DebugEnv, "off")
+	assert.Nil(t, newHttpClientParams().Debug)
+}
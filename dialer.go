@@ -0,0 +1,62 @@
+package httpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func newNetDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+// dialContext returns the dial function used by the transport, or nil to keep
+// the transport default. Hosts mapped to a unix socket are dialed on the
+// socket, every other address is resolved by the client when a DNS option is
+// set and then goes through the custom dialer if any.
+func (s *HttpClientParams) dialContext() DialContextFunc {
+	customResolution := s.customResolution()
+	if s.DialContext == nil && len(s.UnixSockets) == 0 && !customResolution && s.FallbackDelay == 0 {
+		return nil
+	}
+
+	dialer := newNetDialer()
+	dialer.FallbackDelay = s.FallbackDelay
+	dial := dialer.DialContext
+	if s.DialContext != nil {
+		dial = s.DialContext
+	}
+
+	var resolver *hostResolver
+	if customResolution {
+		resolver = newHostResolver(s)
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if socket, ok := s.unixSocketFor(addr); ok {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		if resolver != nil {
+			return resolver.dial(ctx, network, addr, dial)
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+func (s *HttpClientParams) unixSocketFor(addr string) (string, bool) {
+	if len(s.UnixSockets) == 0 {
+		return "", false
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	socket, ok := s.UnixSockets[strings.ToLower(host)]
+	return socket, ok
+}
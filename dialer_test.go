@@ -0,0 +1,52 @@
+package httpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_WithUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + r.URL.Path))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client := NewHttpClient(
+		WithUnixSocket("unix", socket),
+		WithProxy("http://unused.invalid:3128"),
+	)
+
+	_, body, err := client.Get("http://unix/containers/json")
+	assert.NoError(t, err)
+	assert.Equal(t, "unix/containers/json", string(body))
+}
+
+func TestHttpClient_WithDialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+
+	var dialed []string
+	client := NewHttpClient(WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+	}))
+
+	_, body, err := client.Get("http://sidecar:8080/health")
+	assert.NoError(t, err)
+	assert.Equal(t, "sidecar:8080", string(body))
+	assert.Equal(t, []string{"sidecar:8080"}, dialed)
+}
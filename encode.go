@@ -0,0 +1,250 @@
+package httpc
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValuesEncoder is implemented by types that encode themselves into query or
+// form values under key.
+type ValuesEncoder interface {
+	EncodeValues(key string, values *url.Values) error
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	valuesEncoderType = reflect.TypeOf((*ValuesEncoder)(nil)).Elem()
+)
+
+// EncodeValues encodes v into url.Values. v is a struct (or a pointer to
+// one), url.Values or map[string]string.
+//
+// Struct fields are named by the "url" tag, `url:"name,omitempty"`, and
+// fall back to the field name; "-" skips the field. Supported options:
+//
+//	omitempty  skip zero values
+//	comma      join slices with commas instead of repeating the key
+//	unix       encode time.Time as Unix seconds
+//
+// Slices repeat the key, nested structs and maps use "parent[child]" keys,
+// embedded structs are flattened and time.Time defaults to RFC 3339.
+func EncodeValues(v interface{}) (url.Values, error) {
+	switch values := v.(type) {
+	case nil:
+		return url.Values{}, nil
+	case url.Values:
+		return cloneValues(values), nil
+	case map[string]string:
+		encoded := make(url.Values, len(values))
+		for k, value := range values {
+			encoded.Set(k, value)
+		}
+		return encoded, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encode values: expected a struct, got %T", v)
+	}
+
+	values := url.Values{}
+	if err := encodeStruct(values, "", rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// QueryFromStruct builds a Query from v, see EncodeValues.
+func QueryFromStruct(v interface{}) (*Query, error) {
+	values, err := EncodeValues(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{values: values}, nil
+}
+
+func encodeStruct(values url.Values, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		fv := rv.Field(i)
+
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := encodeStruct(values, prefix, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if prefix != "" {
+			name = prefix + "[" + name + "]"
+		}
+		if opts.has("omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		if err := encodeValue(values, name, fv, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeValue(values url.Values, key string, rv reflect.Value, opts tagOptions) error {
+	if rv.Type().Implements(valuesEncoderType) {
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+		return rv.Interface().(ValuesEncoder).EncodeValues(key, &values)
+	}
+
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(key, string(rv.Bytes()))
+			return nil
+		}
+		if opts.has("comma") {
+			parts := make([]string, 0, rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				parts = append(parts, formatScalar(rv.Index(i), opts))
+			}
+			values.Add(key, strings.Join(parts, ","))
+			return nil
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeValue(values, key, rv.Index(i), opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("encode values: unsupported map key type %s for %q", rv.Type().Key(), key)
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			if err := encodeValue(values, key+"["+iter.Key().String()+"]", iter.Value(), opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		if rv.Type() != timeType {
+			return encodeStruct(values, key, rv)
+		}
+	case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return fmt.Errorf("encode values: unsupported type %s for %q", rv.Type(), key)
+	}
+
+	values.Add(key, formatScalar(rv, opts))
+	return nil
+}
+
+func formatScalar(rv reflect.Value, opts tagOptions) string {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Type() == timeType {
+		t := rv.Interface().(time.Time)
+		if opts.has("unix") {
+			return strconv.FormatInt(t.Unix(), 10)
+		}
+		return t.Format(time.RFC3339)
+	}
+	if stringer, ok := rv.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+type tagOptions []string
+
+func parseTag(tag string) (string, tagOptions) {
+	parts := strings.Split(tag, ",")
+	return parts[0], tagOptions(parts[1:])
+}
+
+func (o tagOptions) has(option string) bool {
+	for _, opt := range o {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneValues(values url.Values) url.Values {
+	if clone := cloneMultiMap(values); clone != nil {
+		return clone
+	}
+	return url.Values{}
+}
@@ -0,0 +1,113 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pagination struct {
+	Page  int `url:"page,omitempty"`
+	Limit int `url:"limit"`
+}
+
+type address struct {
+	City string `url:"city"`
+	Zip  string `url:"zip,omitempty"`
+}
+
+type searchParams struct {
+	pagination
+	Query    string            `url:"q"`
+	Tags     []string          `url:"tag"`
+	IDs      []int             `url:"ids,comma"`
+	Active   *bool             `url:"active,omitempty"`
+	Since    time.Time         `url:"since,omitempty"`
+	Until    time.Time         `url:"until,unix"`
+	Address  address           `url:"address"`
+	Labels   map[string]string `url:"labels,omitempty"`
+	Ignored  string            `url:"-"`
+	Untagged string
+	internal string
+}
+
+func TestEncodeValues(t *testing.T) {
+	active := false
+	values, err := EncodeValues(&searchParams{
+		pagination: pagination{Limit: 20},
+		Query:      "go http",
+		Tags:       []string{"a", "b"},
+		IDs:        []int{1, 2, 3},
+		Active:     &active,
+		Until:      time.Unix(1700000000, 0),
+		Address:    address{City: "Porto Alegre"},
+		Labels:     map[string]string{"env": "prod"},
+		Ignored:    "x",
+		Untagged:   "y",
+		internal:   "z",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, url.Values{
+		"limit":         {"20"},
+		"q":             {"go http"},
+		"tag":           {"a", "b"},
+		"ids":           {"1,2,3"},
+		"active":        {"false"},
+		"until":         {"1700000000"},
+		"address[city]": {"Porto Alegre"},
+		"labels[env]":   {"prod"},
+		"Untagged":      {"y"},
+	}, values)
+}
+
+func TestEncodeValues_Errors(t *testing.T) {
+	_, err := EncodeValues("not a struct")
+	assert.Error(t, err)
+
+	_, err = EncodeValues(struct {
+		Fn func() `url:"fn"`
+	}{Fn: func() {}})
+	assert.Error(t, err)
+
+	values, err := EncodeValues(map[string]string{"a": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a=1", values.Encode())
+}
+
+func TestQueryFromStruct(t *testing.T) {
+	query, err := QueryFromStruct(pagination{Page: 2, Limit: 10})
+	assert.NoError(t, err)
+
+	addrs, err := BuildURL("/items", nil, query)
+	assert.NoError(t, err)
+	assert.Equal(t, "/items?limit=10&page=2", addrs)
+}
+
+func TestHttpClient_PostForm(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		assert.NoError(t, r.ParseForm())
+		w.Write([]byte(r.PostForm.Encode()))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient()
+	// the shared form values are not used by per-request forms
+	client.SetFormValue(http.MethodPost, "shared", "value")
+
+	_, body, err := client.PostForm(ts.URL, struct {
+		Name  string   `url:"name"`
+		Roles []string `url:"role"`
+	}{Name: "ana", Roles: []string{"admin", "dev"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "name=ana&role=admin&role=dev", string(body))
+
+	_, body, err = client.PutForm(ts.URL, url.Values{"k": {"v"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "k=v", string(body))
+}
@@ -0,0 +1,524 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const defaultHARBodyLimit = 1 << 20
+
+// HAR is an HTTP Archive 1.2 document.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARCookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings are in milliseconds, -1 when the phase does not apply.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARRecorder records every attempt of the clients it is added to, see
+// WithHARRecorder, as HAR 1.2 entries. Bodies are cut at BodyLimit bytes and
+// secrets are masked by Redactor, or by the default redactor when it is nil.
+// Recording can be switched on and off at runtime.
+type HARRecorder struct {
+	BodyLimit int
+	Redactor  *Redactor
+
+	mu      sync.Mutex
+	enabled bool
+	entries []*harAttempt
+}
+
+// NewHARRecorder returns an enabled recorder keeping up to 1 MiB of each
+// body.
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{BodyLimit: defaultHARBodyLimit, enabled: true}
+}
+
+// WithHARRecorder records the attempts of the client in recorder.
+func WithHARRecorder(recorder *HARRecorder) HttpClientOptions {
+	if recorder == nil {
+		return func(*HttpClientParams) {}
+	}
+	return WithInstrumentation(recorder)
+}
+
+func (r *HARRecorder) Enable() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = true
+}
+
+func (r *HARRecorder) Disable() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = false
+}
+
+func (r *HARRecorder) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// Reset drops the recorded entries.
+func (r *HARRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// HAR returns the recorded entries as a HAR document.
+func (r *HARRecorder) HAR() *HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]HAREntry, 0, len(r.entries))
+	for _, attempt := range r.entries {
+		entries = append(entries, attempt.harEntry())
+	}
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "go-httpc", Version: "1"},
+		Entries: entries,
+	}}
+}
+
+// WriteTo writes the HAR document to w.
+func (r *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Save writes the HAR document to the file at path.
+func (r *HARRecorder) Save(path string) error {
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+type harAttemptKey struct{}
+
+// harAttempt is an attempt being recorded, its response body is captured as
+// the caller reads it.
+type harAttempt struct {
+	entry     HAREntry
+	bodyLimit int
+	redactor  *Redactor
+
+	start, dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, gotConn, wrote, firstByte, end   time.Time
+
+	body     bytes.Buffer
+	bodySize int
+}
+
+func (r *HARRecorder) StartCall(ctx context.Context, _ CallInfo) context.Context {
+	return ctx
+}
+
+func (r *HARRecorder) EndCall(context.Context, CallInfo, *http.Response, error) {}
+
+func (r *HARRecorder) StartAttempt(ctx context.Context, info AttemptInfo) context.Context {
+	if !r.Enabled() {
+		return ctx
+	}
+	redactor := r.Redactor
+	if redactor == nil {
+		redactor = &Redactor{}
+	}
+	attempt := &harAttempt{bodyLimit: r.BodyLimit, redactor: redactor, start: time.Now()}
+	attempt.entry.Comment = "attempt " + strconv.Itoa(info.Attempt)
+
+	r.mu.Lock()
+	r.entries = append(r.entries, attempt)
+	r.mu.Unlock()
+
+	ctx = context.WithValue(ctx, harAttemptKey{}, attempt)
+	return httptrace.WithClientTrace(ctx, attempt.trace(&r.mu))
+}
+
+func (r *HARRecorder) EndAttempt(ctx context.Context, info ResponseInfo) {
+	attempt, _ := ctx.Value(harAttemptKey{}).(*harAttempt)
+	if attempt == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt.recordRequest(info.Request)
+	if info.Response == nil {
+		attempt.end = time.Now()
+		if info.Err != nil {
+			attempt.entry.Response.Content.Comment = info.Err.Error()
+		}
+		return
+	}
+	attempt.recordResponse(info.Response)
+	info.Response.Body = &harBody{ReadCloser: info.Response.Body, mu: &r.mu, attempt: attempt}
+}
+
+func (a *harAttempt) trace(mu *sync.Mutex) *httptrace.ClientTrace {
+	mark := func(t *time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		if t.IsZero() {
+			*t = time.Now()
+		}
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&a.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { mark(&a.dnsDone) },
+		ConnectStart:         func(string, string) { mark(&a.connectStart) },
+		ConnectDone:          func(string, string, error) { mark(&a.connectDone) },
+		TLSHandshakeStart:    func() { mark(&a.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { mark(&a.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { mark(&a.wrote) },
+		GotFirstResponseByte: func() { mark(&a.firstByte) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			mark(&a.gotConn)
+			if addr := info.Conn.RemoteAddr(); addr != nil {
+				mu.Lock()
+				a.entry.ServerIPAddress = hostOnly(addr.String())
+				mu.Unlock()
+			}
+		},
+	}
+}
+
+func (a *harAttempt) recordRequest(req *http.Request) {
+	if req == nil {
+		return
+	}
+	a.entry.StartedDateTime = a.start
+	a.entry.Request = HARRequest{
+		Method:      req.Method,
+		URL:         a.redactor.URL(req.URL.String()),
+		HTTPVersion: req.Proto,
+		Cookies:     a.harCookies(req.Cookies()),
+		Headers:     harHeaders(a.redactor.Header(req.Header)),
+		QueryString: a.harQuery(req.URL),
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	if payload, err := requestPayload(req); err == nil && len(payload) > 0 {
+		a.entry.Request.BodySize = len(payload)
+		text, _ := a.harText(req.Header.Get("Content-Type"), payload)
+		a.entry.Request.PostData = &HARPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     text,
+		}
+	}
+}
+
+func (a *harAttempt) recordResponse(resp *http.Response) {
+	a.entry.Response = HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     a.harCookies(resp.Cookies()),
+		Headers:     harHeaders(a.redactor.Header(resp.Header)),
+		Content:     HARContent{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// harEntry completes the entry with the body and timings recorded so far.
+func (a *harAttempt) harEntry() HAREntry {
+	entry := a.entry
+	end := a.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if a.entry.Response.Status != 0 {
+		text, encoding := a.harText(entry.Response.Content.MimeType, a.body.Bytes())
+		entry.Response.Content.Size = a.bodySize
+		entry.Response.Content.Text = text
+		entry.Response.Content.Encoding = encoding
+		if a.bodySize > a.body.Len() {
+			entry.Response.Content.Comment = "body truncated"
+		}
+		if !a.end.IsZero() {
+			entry.Response.BodySize = a.bodySize
+		}
+	}
+	entry.Time = milliseconds(end.Sub(a.start))
+	entry.Timings = a.harTimings(end)
+	return entry
+}
+
+func (a *harAttempt) harTimings(end time.Time) HARTimings {
+	phase := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return milliseconds(to.Sub(from))
+	}
+	timings := HARTimings{
+		Blocked: -1,
+		DNS:     phase(a.dnsStart, a.dnsDone),
+		Connect: phase(a.connectStart, a.connectDone),
+		SSL:     phase(a.tlsStart, a.tlsDone),
+		Send:    phase(a.gotConn, a.wrote),
+		Wait:    phase(a.wrote, a.firstByte),
+		Receive: phase(a.firstByte, end),
+	}
+	if !a.tlsDone.IsZero() && timings.Connect >= 0 {
+		// HAR counts the TLS handshake in connect as well
+		timings.Connect = phase(a.connectStart, a.tlsDone)
+	}
+	if first := firstTime(a.dnsStart, a.connectStart, a.gotConn); !first.IsZero() {
+		timings.Blocked = milliseconds(first.Sub(a.start))
+	}
+	for _, t := range []*float64{&timings.Send, &timings.Wait, &timings.Receive} {
+		if *t < 0 {
+			*t = 0
+		}
+	}
+	return timings
+}
+
+func (a *harAttempt) harCookies(cookies []*http.Cookie) []HARCookie {
+	masked := a.redactor.redactsHeader("Cookie")
+	harCookies := make([]HARCookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		harCookie := HARCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if masked {
+			harCookie.Value = RedactedValue
+		}
+		if !cookie.Expires.IsZero() {
+			expires := cookie.Expires
+			harCookie.Expires = &expires
+		}
+		harCookies = append(harCookies, harCookie)
+	}
+	return harCookies
+}
+
+func (a *harAttempt) harQuery(u *url.URL) []HARNameValue {
+	query := []HARNameValue{}
+	for key, values := range u.Query() {
+		for _, value := range values {
+			if containsFold(a.redactor.QueryParams, key) {
+				value = RedactedValue
+			}
+			query = append(query, HARNameValue{Name: key, Value: value})
+		}
+	}
+	sortNameValues(query)
+	return query
+}
+
+// harText returns body as text, redacted and cut at the body limit, binary
+// bodies are base64 encoded.
+func (a *harAttempt) harText(contentType string, body []byte) (string, string) {
+	if len(body) == 0 || a.bodyLimit <= 0 {
+		return "", ""
+	}
+	if isBinaryBody(contentType, body) {
+		if len(body) > a.bodyLimit {
+			body = body[:a.bodyLimit]
+		}
+		return base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	body = a.redactor.Body(contentType, body)
+	if len(body) > a.bodyLimit {
+		body = body[:a.bodyLimit]
+		for len(body) > 0 && !utf8.Valid(body) {
+			body = body[:len(body)-1]
+		}
+	}
+	return string(body), ""
+}
+
+// harBody captures the response body as the caller reads it.
+type harBody struct {
+	io.ReadCloser
+	mu      *sync.Mutex
+	attempt *harAttempt
+	once    sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.attempt.bodySize += n
+	if room := b.attempt.bodyLimit - b.attempt.body.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.attempt.body.Write(p[:room])
+	}
+	b.mu.Unlock()
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *harBody) finish() {
+	b.once.Do(func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.attempt.end = time.Now()
+	})
+}
+
+func harHeaders(header http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, HARNameValue{Name: name, Value: value})
+		}
+	}
+	sortNameValues(headers)
+	return headers
+}
+
+func sortNameValues(values []HARNameValue) {
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// firstTime returns the earliest of the non-zero times.
+func firstTime(times ...time.Time) time.Time {
+	var first time.Time
+	for _, t := range times {
+		if !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	return first
+}
+
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
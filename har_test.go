@@ -0,0 +1,111 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHARRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"token":"t0k3n"}`))
+	}))
+	defer ts.Close()
+
+	recorder := NewHARRecorder()
+	recorder.Redactor = &Redactor{QueryParams: []string{"key"}, JSONFields: []string{"password", "token"}}
+	client := NewHttpClient(WithHARRecorder(recorder))
+	client.SetBasicAuth(http.MethodPost, "ana", "s3cr3t")
+
+	_, body, err := client.Post(ts.URL+"/login?key=k&lang=pt", []byte(`{"user":"ana","password":"s3cr3t"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"token":"t0k3n"}`, string(body))
+
+	har := recorder.HAR()
+	assert.Equal(t, "1.2", har.Log.Version)
+	require.Len(t, har.Log.Entries, 1)
+	entry := har.Log.Entries[0]
+
+	assert.Equal(t, http.MethodPost, entry.Request.Method)
+	assert.Equal(t, ts.URL+"/login?key=[REDACTED]&lang=pt", entry.Request.URL)
+	assert.Equal(t, []HARNameValue{{Name: "key", Value: RedactedValue}, {Name: "lang", Value: "pt"}}, entry.Request.QueryString)
+	assert.Contains(t, entry.Request.Headers, HARNameValue{Name: "Authorization", Value: RedactedValue})
+	require.NotNil(t, entry.Request.PostData)
+	assert.JSONEq(t, `{"user":"ana","password":"[REDACTED]"}`, entry.Request.PostData.Text)
+
+	assert.Equal(t, http.StatusOK, entry.Response.Status)
+	assert.Equal(t, "application/json", entry.Response.Content.MimeType)
+	assert.JSONEq(t, `{"id":1,"token":"[REDACTED]"}`, entry.Response.Content.Text)
+	assert.Equal(t, 24, entry.Response.Content.Size)
+	assert.Equal(t, []HARCookie{{Name: "session", Value: RedactedValue, Path: "/"}}, entry.Response.Cookies)
+	assert.Equal(t, "127.0.0.1", entry.ServerIPAddress)
+	assert.True(t, entry.Time > 0)
+	assert.True(t, entry.Timings.Connect >= 0)
+	assert.True(t, entry.Timings.Wait >= 0)
+
+	var out bytes.Buffer
+	_, err = recorder.WriteTo(&out)
+	require.NoError(t, err)
+	assert.NotContains(t, out.String(), "s3cr3t")
+	assert.NotContains(t, out.String(), "t0k3n")
+}
+
+func TestHARRecorder_SwitchAndSave(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0, 1, 2, 3, 4, 5})
+	}))
+	defer ts.Close()
+
+	recorder := NewHARRecorder()
+	recorder.BodyLimit = 4
+	client := NewHttpClient(WithHARRecorder(recorder))
+
+	recorder.Disable()
+	_, _, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.HAR().Log.Entries)
+
+	recorder.Enable()
+	_, body, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Len(t, body, 6)
+
+	path := filepath.Join(t.TempDir(), "calls.har")
+	require.NoError(t, recorder.Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var har HAR
+	require.NoError(t, json.Unmarshal(data, &har))
+	require.Len(t, har.Log.Entries, 1)
+	content := har.Log.Entries[0].Response.Content
+	assert.Equal(t, "base64", content.Encoding)
+	assert.Equal(t, "AAECAw==", content.Text)
+	assert.Equal(t, 6, content.Size)
+	assert.Equal(t, "body truncated", content.Comment)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.HAR().Log.Entries)
+}
+
+func TestHARRecorder_TransportError(t *testing.T) {
+	recorder := NewHARRecorder()
+	client := NewHttpClient(WithHARRecorder(recorder), WithMaxRetries(1))
+
+	_, _, err := client.Get("http://127.0.0.1:1/")
+	assert.Error(t, err)
+	entries := recorder.HAR().Log.Entries
+	require.Len(t, entries, 1)
+	assert.Equal(t, 0, entries[0].Response.Status)
+	assert.NotEmpty(t, entries[0].Response.Content.Comment)
+}
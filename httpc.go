@@ -14,28 +14,51 @@ import (
 	"time"
 )
 
+const formContentType = "application/x-www-form-urlencoded"
+
 type HttpClient struct {
 	sync.RWMutex
-	client       *http.Client
-	headers      map[string]map[string]string
-	forms        map[string]map[string]string
-	basicAuth    map[string]map[string]string
-	params       *HttpClientParams
+	client    *http.Client
+	headers   map[string]http.Header
+	forms     map[string]url.Values
+	basicAuth map[string]map[string]string
+	params    *HttpClientParams
+	balancers map[string]*balancer
+
+	retryBudgets *retryBudgets
+	// debugMu serializes the debug dumps of concurrent calls.
+	debugMu sync.Mutex
 }
 
 func NewHttpClient(opts ...HttpClientOptions) *HttpClient {
 
 	params := newHttpClientParams(opts...)
 
-	client := &http.Client{}
+	client := &http.Client{
+		Transport: params.roundTripper(),
+		Jar:       params.CookieJar,
+	}
 
-	return &HttpClient{
+	c := &HttpClient{
 		client:    client,
-		headers:   make(map[string]map[string]string),
-		forms:     make(map[string]map[string]string),
+		headers:   make(map[string]http.Header),
+		forms:     make(map[string]url.Values),
 		basicAuth: make(map[string]map[string]string),
 		params:    params,
 	}
+	if params.RedirectPolicy != nil {
+		client.CheckRedirect = c.checkRedirect
+	}
+	if len(params.Services) > 0 {
+		c.balancers = make(map[string]*balancer, len(params.Services))
+		for name, service := range params.Services {
+			c.balancers[name] = newBalancer(service, params.MaxEndpointFailures, params.EndpointEjectTime)
+		}
+	}
+	if params.RetryBudget != nil {
+		c.retryBudgets = newRetryBudgets(*params.RetryBudget)
+	}
+	return c
 }
 
 func (c *HttpClient) setHeaders(method string, req *http.Request) {
@@ -43,8 +66,8 @@ func (c *HttpClient) setHeaders(method string, req *http.Request) {
 	defer c.RUnlock()
 	headers, exists := c.headers[methodKey(method)]
 	if exists {
-		for k, v := range headers {
-			req.Header.Set(k, v)
+		for k, values := range headers {
+			req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), values...)
 		}
 	}
 }
@@ -65,22 +88,39 @@ func (c *HttpClient) doRequest(method, addrs string, payload []byte) (*http.Resp
 }
 
 func (c *HttpClient) doRequestWithContext(ctx context.Context, method, addrs string, payload []byte) (*http.Response, []byte, error) {
-	resp, body, err := c.doRequestWithContextRaw(ctx, method, addrs, payload, true)
+	resp, body, err := c.doRequestWithContextRaw(ctx, method, addrs, payload, "", nil, false)
 	return resp, body, err
 }
 
-func (c *HttpClient) buildRequest(ctx context.Context, method, addrs string, payload []byte) (*http.Request, error) {
-	formValues := c.formValuesFor(method)
-	if len(formValues) > 0 {
-		form := url.Values{}
-		for key, value := range formValues {
-			form.Add(key, value)
+func (c *HttpClient) doFormRequest(ctx context.Context, method, addrs string, form interface{}) (*http.Response, []byte, error) {
+	values, err := EncodeValues(form)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding form failed: %w", err)
+	}
+	return c.doRequestWithContextRaw(ctx, method, addrs, []byte(values.Encode()), formContentType, nil, false)
+}
+
+// buildRequest creates the request of an attempt. A raw payload is sent as
+// is, even when empty, instead of the form values set for the method.
+func (c *HttpClient) buildRequest(ctx context.Context, method, addrs string, payload []byte, contentType string, raw bool) (*http.Request, error) {
+	if contentType != "" || raw {
+		req, err := http.NewRequestWithContext(ctx, method, addrs, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
 		}
+		return req, nil
+	}
+
+	form := c.formValuesFor(method)
+	if len(form) > 0 {
 		req, err := http.NewRequestWithContext(ctx, method, addrs, strings.NewReader(form.Encode()))
 		if err != nil {
 			return nil, err
 		}
-		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add("Content-Type", formContentType)
 		return req, nil
 	}
 
@@ -114,6 +154,16 @@ func (c *HttpClient) Patch(addrs string, payload []byte) (*http.Response, []byte
 	return c.doRequest(http.MethodPatch, addrs, payload)
 }
 
+// PostForm sends form, a struct, url.Values or map[string]string encoded as
+// application/x-www-form-urlencoded. See EncodeValues for the struct tags.
+func (c *HttpClient) PostForm(addrs string, form interface{}) (*http.Response, []byte, error) {
+	return c.doFormRequest(context.Background(), http.MethodPost, addrs, form)
+}
+
+func (c *HttpClient) PutForm(addrs string, form interface{}) (*http.Response, []byte, error) {
+	return c.doFormRequest(context.Background(), http.MethodPut, addrs, form)
+}
+
 func (c *HttpClient) Head(addrs string) (*http.Response, []byte, error) {
 	return c.HeadWithContext(context.Background(), addrs)
 }
@@ -138,49 +188,77 @@ func (c *HttpClient) PatchWithContext(ctx context.Context, addrs string, payload
 	return c.doRequestWithContext(ctx, http.MethodPatch, addrs, payload)
 }
 
+func (c *HttpClient) PostFormWithContext(ctx context.Context, addrs string, form interface{}) (*http.Response, []byte, error) {
+	return c.doFormRequest(ctx, http.MethodPost, addrs, form)
+}
+
+func (c *HttpClient) PutFormWithContext(ctx context.Context, addrs string, form interface{}) (*http.Response, []byte, error) {
+	return c.doFormRequest(ctx, http.MethodPut, addrs, form)
+}
+
 func (c *HttpClient) HeadWithContext(ctx context.Context, addrs string) (*http.Response, []byte, error) {
-	resp, body, err := c.doRequestWithContextRaw(ctx, http.MethodHead, addrs, nil, true)
+	resp, body, err := c.doRequestWithContextRaw(ctx, http.MethodHead, addrs, nil, "", nil, false)
 	return resp, body, err
 }
 
+// SetHeader replaces every value of the header key for method.
 func (c *HttpClient) SetHeader(method, key, value string) {
 	c.Lock()
 	defer c.Unlock()
-	method = methodKey(method)
+	headers := c.headersFor(method)
+	deleteHeader(headers, key)
+	headers[key] = []string{value}
+}
 
-	if _, exists := c.headers[method]; !exists {
-		c.headers[method] = make(map[string]string)
+// AddHeader appends value to the values of the header key for method.
+func (c *HttpClient) AddHeader(method, key, value string) {
+	c.Lock()
+	defer c.Unlock()
+	headers := c.headersFor(method)
+	if existing, ok := headerKey(headers, key); ok {
+		key = existing
 	}
-	c.headers[method][key] = value
+	headers[key] = append(headers[key], value)
 }
 
 func (c *HttpClient) DeleteHeader(method, key string) {
 	c.Lock()
 	defer c.Unlock()
-	delete(c.headers[methodKey(method)], key)
+	deleteHeader(c.headers[methodKey(method)], key)
 }
 
+// SetFormValue replaces every value of the form field key for method.
 func (c *HttpClient) SetFormValue(method, key, value string) {
 	c.Lock()
 	defer c.Unlock()
-	method = methodKey(method)
+	c.formsFor(method).Set(key, value)
+}
 
-	if _, exists := c.forms[method]; !exists {
-		c.forms[method] = make(map[string]string)
-	}
-	c.forms[method][key] = value
+// AddFormValue appends value to the values of the form field key for method.
+func (c *HttpClient) AddFormValue(method, key, value string) {
+	c.Lock()
+	defer c.Unlock()
+	c.formsFor(method).Add(key, value)
 }
 
 func (c *HttpClient) DeleteFormValue(method, key string) {
 	c.Lock()
 	defer c.Unlock()
-	delete(c.forms[methodKey(method)], key)
+	c.forms[methodKey(method)].Del(key)
 }
 
+// GetFormValue returns the first value of each form field for method. Use
+// GetFormValues to get every value.
 func (c *HttpClient) GetFormValue(method string) map[string]string {
 	c.RLock()
 	defer c.RUnlock()
-	return cloneStringMap(c.forms[methodKey(method)])
+	return firstValues(c.forms[methodKey(method)])
+}
+
+func (c *HttpClient) GetFormValues(method string) url.Values {
+	c.RLock()
+	defer c.RUnlock()
+	return cloneMultiMap(c.forms[methodKey(method)])
 }
 
 func (c *HttpClient) SetBasicAuth(method, username, password string) {
@@ -200,26 +278,89 @@ func (c *HttpClient) GetBasicAuth(method string) map[string]string {
 }
 
 func (c *HttpClient) SetPatchHeader(key, value string) {
-	c.Lock()
-	defer c.Unlock()
-
-	if _, exists := c.headers[http.MethodPatch]; !exists {
-		c.headers[http.MethodPatch] = make(map[string]string)
-	}
-
-	c.headers[http.MethodPatch][key] = value
+	c.SetHeader(http.MethodPatch, key, value)
 }
 
+// GetHeaders returns the first value of each header for method. Use
+// GetHeaderValues to get every value.
 func (c *HttpClient) GetHeaders(method string) map[string]string {
 	c.RLock()
 	defer c.RUnlock()
-	return cloneStringMap(c.headers[methodKey(method)])
+	return firstValues(c.headers[methodKey(method)])
+}
+
+// GetHeaderValues returns every value of each header for method. Like
+// GetHeaders, the keys are spelled as they were set; they are canonicalized
+// when the request is sent.
+func (c *HttpClient) GetHeaderValues(method string) http.Header {
+	c.RLock()
+	defer c.RUnlock()
+	return http.Header(cloneMultiMap(c.headers[methodKey(method)]))
 }
 
-func (c *HttpClient) formValuesFor(method string) map[string]string {
+func (c *HttpClient) formValuesFor(method string) url.Values {
 	c.RLock()
 	defer c.RUnlock()
-	return cloneStringMap(c.forms[methodKey(method)])
+	return cloneMultiMap(c.forms[methodKey(method)])
+}
+
+// headerKey returns the spelling key was stored with. Header keys keep the
+// caller's spelling, as GetHeaders returns them, but name the same header
+// whatever their case.
+func headerKey(headers http.Header, key string) (string, bool) {
+	canonical := http.CanonicalHeaderKey(key)
+	for k := range headers {
+		if http.CanonicalHeaderKey(k) == canonical {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func deleteHeader(headers http.Header, key string) {
+	for k, ok := headerKey(headers, key); ok; k, ok = headerKey(headers, key) {
+		delete(headers, k)
+	}
+}
+
+func (c *HttpClient) headersFor(method string) http.Header {
+	method = methodKey(method)
+	if _, exists := c.headers[method]; !exists {
+		c.headers[method] = make(http.Header)
+	}
+	return c.headers[method]
+}
+
+func (c *HttpClient) formsFor(method string) url.Values {
+	method = methodKey(method)
+	if _, exists := c.forms[method]; !exists {
+		c.forms[method] = make(url.Values)
+	}
+	return c.forms[method]
+}
+
+func firstValues(values map[string][]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	first := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			first[k] = v[0]
+		}
+	}
+	return first
+}
+
+func cloneMultiMap(values map[string][]string) map[string][]string {
+	if len(values) == 0 {
+		return nil
+	}
+	clone := make(map[string][]string, len(values))
+	for k, v := range values {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
 }
 
 func cloneStringMap(values map[string]string) map[string]string {
@@ -274,11 +415,24 @@ func (c *HttpClient) shouldRetryError(err error) bool {
 	return false
 }
 
-func (c *HttpClient) sleepRetry(ctx context.Context) error {
+// isDialError reports whether err happened while connecting, before the
+// request reached the server.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func (c *HttpClient) retryWait() time.Duration {
 	if c.params == nil || c.params.MaxRetryWait <= 0 {
-		return nil
+		return 0
+	}
+	return time.Second * time.Duration(c.params.MaxRetryWait)
+}
+
+func (c *HttpClient) sleepRetry(ctx context.Context, wait time.Duration) error {
+	if wait <= 0 {
+		return ctx.Err()
 	}
-	wait := time.Second * time.Duration(c.params.MaxRetryWait)
 	timer := time.NewTimer(wait)
 	defer timer.Stop()
 
@@ -290,57 +444,199 @@ func (c *HttpClient) sleepRetry(ctx context.Context) error {
 	}
 }
 
-func (c *HttpClient) doRequestWithContextRaw(ctx context.Context, method, addrs string, payload []byte, readBody bool) (*http.Response, []byte, error) {
+func (c *HttpClient) doRequestWithContextRaw(ctx context.Context, method, addrs string, payload []byte, contentType string, header http.Header, raw bool) (*http.Response, []byte, error) {
+	call := &CallInfo{Method: method, URL: c.resolveURL(addrs), Route: RouteTemplate(ctx), Start: time.Now()}
+	var timings *timingsRecorder
+	if c.timingsEnabled() {
+		timings = &timingsRecorder{}
+		ctx = context.WithValue(ctx, timingsKey{}, timings)
+	}
+	ctx = c.startCall(ctx, *call)
+	resp, body, err := c.doAttempts(ctx, call, payload, contentType, header, raw)
+	call.Duration = time.Since(call.Start)
+	if timings != nil {
+		timings.finish(call.Duration)
+	}
+	c.endCall(ctx, *call, resp, err)
+	c.callDone(*call, resp, err)
+	return resp, body, err
+}
+
+func (c *HttpClient) doAttempts(ctx context.Context, call *CallInfo, payload []byte, contentType string, header http.Header, raw bool) (*http.Response, []byte, error) {
+	method := call.Method
 	attempts := c.retriesForMethod(method)
 	if attempts < 1 {
 		attempts = 1
 	}
 
-	for attempt := 0; attempt < attempts; attempt++ {
-		req, err := c.buildRequest(ctx, method, addrs, payload)
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	if timeout := c.totalTimeoutFor(method); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		cancels = append(cancels, cancel)
+	}
+	attemptTimeout := c.attemptTimeoutFor(method)
+
+	addrs := call.URL
+	balancer, serviceURL := c.balancerFor(addrs)
+	var budget *retryBudget
+	if c.retryBudgets != nil {
+		budget = c.retryBudgets.budgetFor(addrs)
+		budget.recordRequest()
+	}
+	var timings *timingsRecorder
+	if c.timingsEnabled() {
+		timings, _ = ctx.Value(timingsKey{}).(*timingsRecorder)
+	}
+	var tried map[*endpoint]struct{}
+	history := &RetryError{}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		call.Attempts = attempt
+		target := addrs
+		var ep *endpoint
+		if balancer != nil {
+			var err error
+			if ep, err = balancer.pick(tried); err != nil {
+				return nil, nil, fmt.Errorf("selecting endpoint failed: %w", err)
+			}
+			if tried == nil {
+				tried = make(map[*endpoint]struct{})
+			}
+			tried[ep] = struct{}{}
+			target = endpointURL(serviceURL, ep)
+		}
+
+		attemptCtx := ctx
+		if attemptTimeout > 0 {
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(ctx, attemptTimeout)
+			cancels = append(cancels, cancel)
+		}
+		if timings != nil {
+			attemptCtx = timings.trace(attemptCtx, attempt)
+		}
+
+		req, err := c.buildRequest(attemptCtx, method, target, payload, contentType, raw)
 		if err != nil {
+			if ep != nil {
+				balancer.release(ep)
+			}
 			return nil, nil, fmt.Errorf("creating request failed: %w", err)
 		}
 
 		c.setHeaders(method, req)
 		c.setBasicAuth(method, req)
+		for k, values := range header {
+			req.Header[k] = append([]string(nil), values...)
+		}
 		c.applyRequestHooks(req)
+		info := AttemptInfo{Method: method, URL: target, Route: call.Route, Attempt: attempt, Request: req, Start: time.Now()}
+		req = c.startAttempt(req, info)
+		info.Request = req
+		if err := c.signRequest(req); err != nil {
+			if ep != nil {
+				balancer.release(ep)
+			}
+			err = fmt.Errorf("signing request failed: %w", err)
+			c.endAttempt(req.Context(), ResponseInfo{AttemptInfo: info, Err: err})
+			return nil, nil, err
+		}
 
+		info.Start = time.Now()
+		c.attemptStarted(info)
 		resp, err := c.client.Do(req)
-		if err != nil {
-			if c.shouldRetryError(err) && attempt+1 < attempts {
-				if sleepErr := c.sleepRetry(ctx); sleepErr != nil {
-					return nil, nil, sleepErr
-				}
-				continue
+		record := AttemptRecord{Attempt: attempt, Duration: time.Since(info.Start)}
+		if timings != nil && err == nil {
+			resp.Body = &timedBody{ReadCloser: resp.Body, done: timings.bodyDone}
+		}
+		c.debugAttempt(info, resp, err, record.Duration)
+		if ep != nil {
+			balancer.done(ep, err != nil || resp.StatusCode >= 500)
+		}
+		result := ResponseInfo{AttemptInfo: info, Response: resp, Err: err, Duration: record.Duration}
+		c.endAttempt(req.Context(), result)
+		c.responseReceived(result)
+		c.logAttempt(req.Context(), result, err == nil && c.isSuccess(method, resp))
+
+		var retryable bool
+		switch {
+		case err != nil:
+			record.Err = fmt.Errorf("request failed: %w", err)
+			retryable = c.shouldRetryError(err) || (ep != nil && isDialError(err))
+		case !c.isSuccess(method, resp):
+			record.StatusCode = resp.StatusCode
+			retryable = c.shouldRetryStatus(resp.StatusCode)
+		default:
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, nil, fmt.Errorf("reading response failed: %w", err)
+			}
+			return resp, body, nil
+		}
+		final := !retryable || attempt == attempts
+		reason := stopReason(ctx, retryable)
+		var wait time.Duration
+		if !final {
+			// the time budget is checked first, so no retry token is spent on
+			// a retry that is not made
+			wait = c.retryWait()
+			switch {
+			case !c.hasTimeForRetry(ctx, wait):
+				final, reason = true, StopTimeBudget
+			case budget != nil && !budget.withdraw():
+				final, reason = true, StopRetryBudget
 			}
-			return nil, nil, fmt.Errorf("request failed: %w", err)
 		}
 
-		if resp.StatusCode >= 400 {
-			if c.shouldRetryStatus(resp.StatusCode) && attempt+1 < attempts {
+		if err == nil {
+			var bts []byte
+			if final {
+				bts, _ = io.ReadAll(resp.Body)
+			} else {
 				io.Copy(io.Discard, resp.Body)
-				resp.Body.Close()
-				if sleepErr := c.sleepRetry(ctx); sleepErr != nil {
-					return nil, nil, sleepErr
-				}
-				continue
 			}
-			bts, _ := io.ReadAll(resp.Body)
 			resp.Body.Close()
-			return nil, nil, fmt.Errorf("http error: status(%d) %s", resp.StatusCode, string(bts))
+			record.Err = &StatusError{
+				StatusCode: resp.StatusCode,
+				Status:     resp.Status,
+				Header:     resp.Header,
+				Body:       bts,
+			}
+			if final {
+				history.stop(record, reason)
+				if c.responseOnError() {
+					return resp, bts, history
+				}
+				return nil, nil, history
+			}
 		}
 
-		if !readBody {
-			return resp, nil, nil
+		if final {
+			return nil, nil, history.stop(record, reason)
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return nil, nil, fmt.Errorf("reading response failed: %w", err)
+		record.Wait = wait
+		history.Attempts = append(history.Attempts, record)
+		c.retrying(RetryInfo{
+			AttemptInfo: info,
+			Err:         record.Err,
+			StatusCode:  record.StatusCode,
+			Duration:    record.Duration,
+			Wait:        record.Wait,
+		})
+		if err := c.sleepRetry(ctx, record.Wait); err != nil {
+			history.Reason = StopContextDone
+			history.Cause = err
+			return nil, nil, history
 		}
-		return resp, body, nil
 	}
 
 	return nil, nil, fmt.Errorf("request failed: exhausted retries")
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -60,6 +61,27 @@ func TestHttpClient_SetHeader(t *testing.T) {
 	}
 }
 
+func TestHttpClient_SetHeaderKeepsKeySpelling(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Join(r.Header.Values("X-Api-Key"), ",")))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient()
+	client.SetHeader(http.MethodGet, "x-api-key", "k")
+	assert.Equal(t, map[string]string{"x-api-key": "k"}, client.GetHeaders(http.MethodGet))
+
+	client.AddHeader(http.MethodGet, "X-API-KEY", "k2")
+	assert.Equal(t, []string{"k", "k2"}, client.GetHeaderValues(http.MethodGet)["x-api-key"])
+
+	_, body, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "k,k2", string(body))
+
+	client.DeleteHeader(http.MethodGet, "X-Api-Key")
+	assert.Empty(t, client.GetHeaders(http.MethodGet))
+}
+
 func TestHttpClient_SetBasicAuth(t *testing.T) {
 	client := NewHttpClient()
 	client.SetBasicAuth(http.MethodGet, "TestUser", "TestPass")
@@ -480,3 +502,32 @@ func TestHttpClient_RetryStatusCodePerMethod(t *testing.T) {
 	assert.Equal(t, "ok", string(body))
 	assert.Equal(t, 3, attempts)
 }
+
+func TestHttpClient_MultiValuedHeadersAndForms(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		w.Write([]byte(strings.Join(r.Header.Values("Accept"), ",") + "|" + r.PostForm.Encode()))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient()
+	client.SetHeader(http.MethodPost, "Accept", "text/plain")
+	client.AddHeader(http.MethodPost, "Accept", "application/json")
+	client.AddFormValue(http.MethodPost, "tag", "a")
+	client.AddFormValue(http.MethodPost, "tag", "b")
+
+	assert.Equal(t, "text/plain", client.GetHeaders(http.MethodPost)["Accept"])
+	assert.Equal(t, []string{"text/plain", "application/json"}, client.GetHeaderValues(http.MethodPost).Values("Accept"))
+	assert.Equal(t, "a", client.GetFormValue(http.MethodPost)["tag"])
+	assert.Equal(t, []string{"a", "b"}, client.GetFormValues(http.MethodPost)["tag"])
+
+	_, body, err := client.Post(ts.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain,application/json|tag=a&tag=b", string(body))
+
+	client.SetHeader(http.MethodPost, "accept", "*/*")
+	client.SetFormValue(http.MethodPost, "tag", "c")
+	_, body, err = client.Post(ts.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "*/*|tag=c", string(body))
+}
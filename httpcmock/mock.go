@@ -0,0 +1,355 @@
+// Package httpcmock is an in-memory transport for unit tests of code using
+// httpc. Tests register the requests they expect and the responses to
+// script, and check that every expectation was met.
+//
+//	mock := httpcmock.New()
+//	mock.Expect(http.MethodGet, "/users/*").
+//		WithHeader("Accept", "application/json").
+//		Respond(http.StatusServiceUnavailable, "").
+//		Respond(http.StatusOK, `{"name":"ana"}`).
+//		Times(2)
+//	client := httpc.NewHttpClient(mock.ClientOption())
+//	// ... exercise the code under test
+//	mock.AssertExpectations(t)
+package httpcmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	httpc "github.com/thiagozs/go-httpc"
+)
+
+// ErrUnexpectedRequest is returned for requests no expectation matches.
+var ErrUnexpectedRequest = errors.New("httpcmock: unexpected request")
+
+// TestingT is the part of testing.TB used to report unmet expectations.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Responder builds the response to a request, the request body has already
+// been read and is passed in body.
+type Responder func(req *http.Request, body []byte) (*http.Response, error)
+
+// Transport is an http.RoundTripper answering from expectations, it never
+// uses the network. It is safe for concurrent use.
+type Transport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// New returns a transport without expectations.
+func New() *Transport {
+	return &Transport{}
+}
+
+// ClientOption installs the transport in the client.
+func (m *Transport) ClientOption() httpc.HttpClientOptions {
+	return httpc.WithTransport(m)
+}
+
+// Expect registers an expected request. An empty or "*" method matches any
+// method. pattern is matched with path.Match against the request path when it
+// starts with "/" and against the URL without its query otherwise, an empty
+// or "*" pattern matches any URL. Use WithQuery to match the query.
+// Expectations are tried in the order they were registered and match once
+// unless Times or AnyTimes is set.
+func (m *Transport) Expect(method, pattern string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &Expectation{
+		transport: m,
+		method:    strings.ToUpper(method),
+		pattern:   pattern,
+		times:     1,
+	}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// ExpectationsWereMet returns an error listing the expectations not called
+// as many times as expected and the unexpected requests, if any.
+func (m *Transport) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var problems []string
+	for _, e := range m.expectations {
+		if e.times > 0 && e.calls != e.times {
+			problems = append(problems, fmt.Sprintf("%s: called %d of %d times", e, e.calls, e.times))
+		}
+	}
+	for _, req := range m.unexpected {
+		problems = append(problems, "unexpected request "+req)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("httpcmock: " + strings.Join(problems, "; "))
+}
+
+// AssertExpectations reports the unmet expectations on t and returns whether
+// they were all met.
+func (m *Transport) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	if err := m.ExpectationsWereMet(); err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	return true
+}
+
+// Reset removes the expectations and the unexpected requests recorded.
+func (m *Transport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = nil
+	m.unexpected = nil
+}
+
+// RoundTrip answers req with the first matching expectation that has calls
+// left, or fails with ErrUnexpectedRequest.
+func (m *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	var responder Responder
+	for _, e := range m.expectations {
+		if (e.times == 0 || e.calls < e.times) && e.matches(req, body) {
+			responder = e.responder(e.calls)
+			e.calls++
+			break
+		}
+	}
+	if responder == nil {
+		m.unexpected = append(m.unexpected, req.Method+" "+req.URL.Redacted())
+	}
+	m.mu.Unlock()
+
+	if responder == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, req.Method, req.URL.Redacted())
+	}
+	resp, err := responder(req, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Request == nil {
+		resp.Request = req
+	}
+	return resp, nil
+}
+
+// Expectation is an expected request and its scripted responses.
+type Expectation struct {
+	transport  *Transport
+	method     string
+	pattern    string
+	matchers   []func(*http.Request, []byte) bool
+	responders []Responder
+	times      int
+	calls      int
+}
+
+func (e *Expectation) String() string {
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + e.pattern
+}
+
+// WithHeader requires the request header name to have value.
+func (e *Expectation) WithHeader(name, value string) *Expectation {
+	return e.Match(func(req *http.Request, _ []byte) bool {
+		for _, v := range req.Header.Values(name) {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithQuery requires the query parameter name to have value.
+func (e *Expectation) WithQuery(name, value string) *Expectation {
+	return e.Match(func(req *http.Request, _ []byte) bool {
+		for _, v := range req.URL.Query()[name] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithBody requires the request body to be body.
+func (e *Expectation) WithBody(body string) *Expectation {
+	return e.Match(func(_ *http.Request, got []byte) bool {
+		return string(got) == body
+	})
+}
+
+// WithJSONBody requires the request body to be JSON equal to body, whatever
+// its formatting and key order.
+func (e *Expectation) WithJSONBody(body string) *Expectation {
+	var want interface{}
+	if err := json.Unmarshal([]byte(body), &want); err != nil {
+		panic(fmt.Sprintf("httpcmock: invalid JSON body %q: %v", body, err))
+	}
+	return e.Match(func(_ *http.Request, got []byte) bool {
+		var v interface{}
+		return json.Unmarshal(got, &v) == nil && reflect.DeepEqual(v, want)
+	})
+}
+
+// Match adds a custom matcher of the request and its body.
+func (e *Expectation) Match(matcher func(req *http.Request, body []byte) bool) *Expectation {
+	e.transport.mu.Lock()
+	defer e.transport.mu.Unlock()
+	e.matchers = append(e.matchers, matcher)
+	return e
+}
+
+// Respond scripts a response with status, body and headers given as name,
+// value pairs. Each call of the expectation uses the next scripted response,
+// the last one is repeated. Without responses the expectation answers 200
+// with an empty body.
+func (e *Expectation) Respond(status int, body string, headers ...string) *Expectation {
+	header := http.Header{}
+	for i := 0; i+1 < len(headers); i += 2 {
+		header.Add(headers[i], headers[i+1])
+	}
+	return e.RespondWith(func(req *http.Request, _ []byte) (*http.Response, error) {
+		return Response(req, status, []byte(body), header.Clone()), nil
+	})
+}
+
+// RespondJSON scripts a response with status and v encoded as JSON.
+func (e *Expectation) RespondJSON(status int, v interface{}) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpcmock: encoding response: %v", err))
+	}
+	return e.Respond(status, string(body), "Content-Type", "application/json")
+}
+
+// RespondError scripts a transport error, such as a timeout.
+func (e *Expectation) RespondError(err error) *Expectation {
+	return e.RespondWith(func(*http.Request, []byte) (*http.Response, error) {
+		return nil, err
+	})
+}
+
+// RespondWith scripts a response built by responder.
+func (e *Expectation) RespondWith(responder Responder) *Expectation {
+	e.transport.mu.Lock()
+	defer e.transport.mu.Unlock()
+	e.responders = append(e.responders, responder)
+	return e
+}
+
+// Times sets how many times the expectation must be called.
+func (e *Expectation) Times(n int) *Expectation {
+	if n < 1 {
+		panic("httpcmock: Times needs a positive count, use AnyTimes instead")
+	}
+	e.transport.mu.Lock()
+	defer e.transport.mu.Unlock()
+	e.times = n
+	return e
+}
+
+// AnyTimes lets the expectation be called any number of times, including
+// none.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.transport.mu.Lock()
+	defer e.transport.mu.Unlock()
+	e.times = 0
+	return e
+}
+
+// Calls returns how many times the expectation was called.
+func (e *Expectation) Calls() int {
+	e.transport.mu.Lock()
+	defer e.transport.mu.Unlock()
+	return e.calls
+}
+
+func (e *Expectation) matches(req *http.Request, body []byte) bool {
+	if e.method != "" && e.method != "*" && e.method != req.Method {
+		return false
+	}
+	if !matchURL(e.pattern, req.URL) {
+		return false
+	}
+	for _, match := range e.matchers {
+		if !match(req, body) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) responder(call int) Responder {
+	switch {
+	case len(e.responders) == 0:
+		return func(req *http.Request, _ []byte) (*http.Response, error) {
+			return Response(req, http.StatusOK, nil, nil), nil
+		}
+	case call < len(e.responders):
+		return e.responders[call]
+	default:
+		return e.responders[len(e.responders)-1]
+	}
+}
+
+func matchURL(pattern string, u *url.URL) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	subject := u.Path
+	if !strings.HasPrefix(pattern, "/") {
+		subject = u.Scheme + "://" + u.Host + u.Path
+	}
+	ok, err := path.Match(pattern, subject)
+	return err == nil && ok
+}
+
+// Response returns a response to req with status, body and header.
+func Response(req *http.Request, status int, body []byte, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
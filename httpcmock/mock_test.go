@@ -0,0 +1,130 @@
+package httpcmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpc "github.com/thiagozs/go-httpc"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestTransport_Expectations(t *testing.T) {
+	mock := New()
+	mock.Expect(http.MethodGet, "/users/*").
+		WithQuery("fields", "name").
+		RespondJSON(http.StatusOK, map[string]string{"name": "ana"})
+	mock.Expect(http.MethodPost, "https://api.example.com/users").
+		WithHeader("X-Tenant", "acme").
+		WithJSONBody(`{"name": "bia", "age": 30}`).
+		Respond(http.StatusCreated, "created", "Location", "/users/2")
+
+	client := httpc.NewHttpClient(mock.ClientOption())
+	resp, body, err := client.Get("https://api.example.com/users/1?fields=name")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"ana"}`, string(body))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	resp, body, err = client.Do(context.Background(), &httpc.Request{
+		Method: http.MethodPost,
+		URL:    "https://api.example.com/users",
+		Header: http.Header{"X-Tenant": {"acme"}, "Content-Type": {"application/json"}},
+		Body:   []byte(`{"age":30,"name":"bia"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/users/2", resp.Header.Get("Location"))
+	assert.Equal(t, "created", string(body))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.True(t, mock.AssertExpectations(t))
+}
+
+func TestTransport_ScriptedRetries(t *testing.T) {
+	mock := New()
+	users := mock.Expect(http.MethodGet, "/users").
+		RespondError(timeoutError{}).
+		Respond(http.StatusServiceUnavailable, "").
+		Respond(http.StatusOK, "ok").
+		Times(3)
+
+	client := httpc.NewHttpClient(
+		mock.ClientOption(),
+		httpc.WithRetryStatusCodes(http.StatusServiceUnavailable),
+		httpc.WithMaxRetryWait(0),
+	)
+	_, body, err := client.Get("http://example.com/users")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, 3, users.Calls())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// the expectation is used up
+	_, _, err = client.Get("http://example.com/users")
+	assert.ErrorIs(t, err, ErrUnexpectedRequest)
+}
+
+func TestTransport_UnmetExpectations(t *testing.T) {
+	mock := New()
+	mock.Expect(http.MethodGet, "/health").AnyTimes()
+	mock.Expect(http.MethodDelete, "/users/*").Times(2)
+	mock.Expect("", "/users/*").WithBody("nope")
+
+	client := httpc.NewHttpClient(mock.ClientOption())
+	_, _, err := client.Delete("http://example.com/users/1", nil)
+	require.NoError(t, err)
+	_, _, err = client.Put("http://example.com/users/1", []byte("yes"))
+	assert.ErrorIs(t, err, ErrUnexpectedRequest)
+
+	err = mock.ExpectationsWereMet()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DELETE /users/*: called 1 of 2 times")
+	assert.Contains(t, err.Error(), "* /users/*: called 0 of 1 times")
+	assert.Contains(t, err.Error(), "unexpected request PUT http://example.com/users/1")
+	assert.NotContains(t, err.Error(), "/health")
+
+	ft := &fakeT{}
+	assert.False(t, mock.AssertExpectations(ft))
+	assert.Len(t, ft.errors, 1)
+
+	mock.Reset()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransport_RespondWith(t *testing.T) {
+	mock := New()
+	mock.Expect("*", "*").AnyTimes().RespondWith(func(req *http.Request, body []byte) (*http.Response, error) {
+		if len(body) == 0 {
+			return nil, errors.New("empty body")
+		}
+		return Response(req, http.StatusOK, body, nil), nil
+	})
+
+	client := httpc.NewHttpClient(mock.ClientOption())
+	_, body, err := client.Post("http://example.com/echo", []byte("ping"))
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(body))
+
+	_, _, err = client.Get("http://example.com/echo")
+	assert.ErrorContains(t, err, "empty body")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
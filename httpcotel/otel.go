@@ -0,0 +1,233 @@
+// Package httpcotel traces and measures httpc calls with OpenTelemetry.
+//
+// Each call gets a client span with a child span per attempt, the attempt
+// requests carry the W3C traceparent and tracestate headers, and every
+// attempt is recorded in the http.client.request.duration histogram.
+package httpcotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	httpc "github.com/thiagozs/go-httpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/thiagozs/go-httpc/httpcotel"
+
+	urlTemplateKey = attribute.Key("url.template")
+)
+
+// durationBuckets are the bucket boundaries advised by the HTTP semantic
+// conventions, in seconds.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider, the global one by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider, the global one by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagator sets the propagator injected into the attempt requests, W3C
+// trace context by default.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+type instrumentation struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	duration   metric.Float64Histogram
+}
+
+// New returns the instrumentation, add it with httpc.WithInstrumentation.
+func New(opts ...Option) (httpc.Instrumentation, error) {
+	cfg := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	duration, err := cfg.meterProvider.Meter(instrumentationName).Float64Histogram(
+		"http.client.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP client requests."),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating duration histogram failed: %w", err)
+	}
+
+	return &instrumentation{
+		tracer:     cfg.tracerProvider.Tracer(instrumentationName),
+		propagator: cfg.propagator,
+		duration:   duration,
+	}, nil
+}
+
+func (i *instrumentation) StartCall(ctx context.Context, call httpc.CallInfo) context.Context {
+	ctx, _ = i.tracer.Start(ctx, spanName(call.Method, call.Route),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(call.Start),
+		trace.WithAttributes(requestAttributes(call.Method, call.URL, call.Route)...),
+	)
+	return ctx
+}
+
+func (i *instrumentation) EndCall(ctx context.Context, call httpc.CallInfo, resp *http.Response, err error) {
+	span := trace.SpanFromContext(ctx)
+	if call.Attempts > 1 {
+		span.SetAttributes(semconv.HTTPRequestResendCount(call.Attempts - 1))
+	}
+	if resp != nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	}
+	if err != nil {
+		span.SetAttributes(semconv.ErrorTypeKey.String(errorType(0, err)))
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(call.Start.Add(call.Duration)))
+}
+
+func (i *instrumentation) StartAttempt(ctx context.Context, info httpc.AttemptInfo) context.Context {
+	attrs := requestAttributes(info.Method, info.URL, info.Route)
+	if info.Attempt > 1 {
+		attrs = append(attrs, semconv.HTTPRequestResendCount(info.Attempt-1))
+	}
+	ctx, _ = i.tracer.Start(ctx, spanName(info.Method, info.Route),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	i.propagator.Inject(ctx, propagation.HeaderCarrier(info.Request.Header))
+	return ctx
+}
+
+func (i *instrumentation) EndAttempt(ctx context.Context, info httpc.ResponseInfo) {
+	span := trace.SpanFromContext(ctx)
+
+	attrs := metricAttributes(info.Method, info.URL, info.Route)
+	var status int
+	if info.Response != nil {
+		status = info.Response.StatusCode
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		attrs = append(attrs, semconv.HTTPResponseStatusCode(status))
+	}
+	if status >= 400 || info.Err != nil {
+		errType := semconv.ErrorTypeKey.String(errorType(status, info.Err))
+		span.SetAttributes(errType)
+		attrs = append(attrs, errType)
+		if info.Err != nil {
+			span.SetStatus(codes.Error, info.Err.Error())
+		} else {
+			span.SetStatus(codes.Error, "")
+		}
+	}
+	span.End()
+
+	i.duration.Record(ctx, info.Duration.Seconds(), metric.WithAttributes(attrs...))
+}
+
+func spanName(method, route string) string {
+	if route == "" {
+		return method
+	}
+	return method + " " + route
+}
+
+func requestAttributes(method, addrs, route string) []attribute.KeyValue {
+	attrs := metricAttributes(method, addrs, route)
+	if u, err := url.Parse(addrs); err == nil {
+		u.User = nil
+		attrs = append(attrs, semconv.URLFull(u.String()))
+	}
+	return attrs
+}
+
+func metricAttributes(method, addrs, route string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.HTTPRequestMethodKey.String(method)}
+	if u, err := url.Parse(addrs); err == nil {
+		attrs = append(attrs, semconv.URLScheme(u.Scheme), semconv.ServerAddress(u.Hostname()))
+		if port := serverPort(u); port > 0 {
+			attrs = append(attrs, semconv.ServerPort(port))
+		}
+	}
+	if route != "" {
+		attrs = append(attrs, urlTemplateKey.String(route))
+	}
+	return attrs
+}
+
+func serverPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	switch u.Scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	}
+	return 0
+}
+
+// errorType describes a failure with a low cardinality value: the status code
+// of unsuccessful responses, "timeout" or the type of the underlying error.
+func errorType(status int, err error) string {
+	if status >= 400 {
+		return strconv.Itoa(status)
+	}
+
+	var retryErr *httpc.RetryError
+	if errors.As(err, &retryErr) && retryErr.Last() != nil {
+		err = retryErr.Last()
+	}
+	var statusErr *httpc.StatusError
+	if errors.As(err, &statusErr) {
+		return strconv.Itoa(statusErr.StatusCode)
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
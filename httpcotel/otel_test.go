@@ -0,0 +1,109 @@
+package httpcotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpc "github.com/thiagozs/go-httpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInstrumentation(t *testing.T) {
+	var hits int32
+	var traceparents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	inst, err := New(WithTracerProvider(tracerProvider), WithMeterProvider(meterProvider))
+	require.NoError(t, err)
+	client := httpc.NewHttpClient(
+		httpc.WithInstrumentation(inst),
+		httpc.WithRetryStatusCodes(http.StatusServiceUnavailable),
+		httpc.WithMaxRetryWait(0),
+	)
+
+	ctx := httpc.WithRouteTemplate(context.Background(), "/users/{id}")
+	_, body, err := client.GetWithContext(ctx, ts.URL+"/users/42")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	first, second, call := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "GET /users/{id}", call.Name)
+	assert.Equal(t, trace.SpanKindClient, call.SpanKind)
+	assert.Equal(t, codes.Unset, call.Status.Code)
+	assert.Contains(t, call.Attributes, attribute.Int("http.request.resend_count", 1))
+	assert.Contains(t, call.Attributes, attribute.Int("http.response.status_code", 200))
+	assert.Contains(t, call.Attributes, attribute.String("url.template", "/users/{id}"))
+	assert.Contains(t, call.Attributes, attribute.String("url.full", ts.URL+"/users/42"))
+
+	for _, attempt := range []sdktrace.ReadOnlySpan{first.Snapshot(), second.Snapshot()} {
+		assert.Equal(t, call.SpanContext.SpanID(), attempt.Parent().SpanID())
+		assert.Equal(t, call.SpanContext.TraceID(), attempt.SpanContext().TraceID())
+	}
+	assert.Equal(t, codes.Error, first.Status.Code)
+	assert.Contains(t, first.Attributes, attribute.String("error.type", "503"))
+	assert.Contains(t, second.Attributes, attribute.Int("http.request.resend_count", 1))
+
+	// each attempt propagates its own span
+	require.Len(t, traceparents, 2)
+	assert.Contains(t, traceparents[0], first.SpanContext.SpanID().String())
+	assert.Contains(t, traceparents[1], second.SpanContext.SpanID().String())
+
+	var metrics metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &metrics))
+	require.Len(t, metrics.ScopeMetrics, 1)
+	require.Len(t, metrics.ScopeMetrics[0].Metrics, 1)
+	duration := metrics.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "http.client.request.duration", duration.Name)
+	assert.Equal(t, "s", duration.Unit)
+	histogram := duration.Data.(metricdata.Histogram[float64])
+	assert.Len(t, histogram.DataPoints, 2)
+}
+
+func TestInstrumentation_Failure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	inst, err := New(WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+	require.NoError(t, err)
+	client := httpc.NewHttpClient(httpc.WithInstrumentation(inst))
+
+	_, _, err = client.Get(ts.URL)
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	call := spans[1]
+	assert.Equal(t, "GET", call.Name)
+	assert.Equal(t, codes.Error, call.Status.Code)
+	assert.Contains(t, call.Attributes, attribute.String("error.type", "404"))
+}
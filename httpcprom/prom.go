@@ -0,0 +1,171 @@
+// Package httpcprom records httpc calls in Prometheus metrics.
+//
+//	recorder := httpcprom.NewRecorder()
+//	prometheus.MustRegister(recorder)
+//	client := httpc.NewHttpClient(httpc.WithMetrics(recorder))
+package httpcprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	httpc "github.com/thiagozs/go-httpc"
+)
+
+var (
+	labelNames       = []string{"host", "method", "route"}
+	statusLabelNames = []string{"host", "method", "route", "status"}
+)
+
+type config struct {
+	namespace   string
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+// Option configures the recorder.
+type Option func(*config)
+
+// WithNamespace sets the metric name prefix, "httpc" by default.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithBuckets sets the buckets of the duration histograms, in seconds.
+func WithBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// Recorder is an httpc.MetricsRecorder and a prometheus.Collector. The
+// status label is the response status code, or "error" when no response was
+// received.
+type Recorder struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	attempts        *prometheus.CounterVec
+	attemptDuration *prometheus.HistogramVec
+	retries         *prometheus.CounterVec
+	bytesSent       *prometheus.CounterVec
+	bytesReceived   *prometheus.CounterVec
+	inFlight        *prometheus.GaugeVec
+}
+
+var _ httpc.MetricsRecorder = (*Recorder)(nil)
+
+// NewRecorder returns a recorder, register it before use.
+func NewRecorder(opts ...Option) *Recorder {
+	cfg := &config{
+		namespace: "httpc",
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	counter := func(name, help string, labels []string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: cfg.constLabels,
+		}, labels)
+	}
+	histogram := func(name, help string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: cfg.constLabels,
+			Buckets:     cfg.buckets,
+		}, labelNames)
+	}
+
+	return &Recorder{
+		requests:        counter("requests_total", "Calls made, including their retries.", statusLabelNames),
+		requestDuration: histogram("request_duration_seconds", "Duration of the calls, including their retries."),
+		attempts:        counter("attempts_total", "Attempts sent.", statusLabelNames),
+		attemptDuration: histogram("attempt_duration_seconds", "Duration of the attempts."),
+		retries:         counter("retries_total", "Attempts retrying a failed attempt.", labelNames),
+		bytesSent:       counter("request_bytes_total", "Request body bytes sent.", labelNames),
+		bytesReceived:   counter("response_bytes_total", "Response body bytes received.", labelNames),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   cfg.namespace,
+			Name:        "requests_in_flight",
+			Help:        "Calls in flight.",
+			ConstLabels: cfg.constLabels,
+		}, labelNames),
+	}
+}
+
+func (r *Recorder) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		r.requests, r.requestDuration, r.attempts, r.attemptDuration,
+		r.retries, r.bytesSent, r.bytesReceived, r.inFlight,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range r.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range r.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+func (r *Recorder) CallStarted(labels httpc.MetricLabels) {
+	r.inFlight.WithLabelValues(labelValues(labels)...).Inc()
+}
+
+func (r *Recorder) CallFinished(labels httpc.MetricLabels, status int, duration time.Duration) {
+	values := labelValues(labels)
+	r.inFlight.WithLabelValues(values...).Dec()
+	r.requests.WithLabelValues(append(values, statusValue(status))...).Inc()
+	r.requestDuration.WithLabelValues(values...).Observe(duration.Seconds())
+}
+
+func (r *Recorder) AttemptFinished(labels httpc.MetricLabels, status int, duration time.Duration) {
+	values := labelValues(labels)
+	r.attempts.WithLabelValues(append(values, statusValue(status))...).Inc()
+	r.attemptDuration.WithLabelValues(values...).Observe(duration.Seconds())
+}
+
+func (r *Recorder) Retried(labels httpc.MetricLabels) {
+	r.retries.WithLabelValues(labelValues(labels)...).Inc()
+}
+
+func (r *Recorder) BytesSent(labels httpc.MetricLabels, n int64) {
+	r.bytesSent.WithLabelValues(labelValues(labels)...).Add(float64(n))
+}
+
+func (r *Recorder) BytesReceived(labels httpc.MetricLabels, n int64) {
+	r.bytesReceived.WithLabelValues(labelValues(labels)...).Add(float64(n))
+}
+
+func labelValues(labels httpc.MetricLabels) []string {
+	return []string{labels.Host, labels.Method, labels.Route}
+}
+
+func statusValue(status int) string {
+	if status == 0 {
+		return "error"
+	}
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,74 @@
+package httpcprom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpc "github.com/thiagozs/go-httpc"
+)
+
+func TestRecorder(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer ts.Close()
+
+	registry := prometheus.NewPedanticRegistry()
+	recorder := NewRecorder()
+	require.NoError(t, registry.Register(recorder))
+
+	client := httpc.NewHttpClient(
+		httpc.WithMetrics(recorder),
+		httpc.WithRetryStatusCodes(http.StatusServiceUnavailable),
+		httpc.WithMaxRetryWait(0),
+	)
+	ctx := httpc.WithRouteTemplate(context.Background(), "/greet")
+	_, _, err := client.PostWithContext(ctx, ts.URL+"/greet", []byte("hi"))
+	require.NoError(t, err)
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	expected := `
+# HELP httpc_attempts_total Attempts sent.
+# TYPE httpc_attempts_total counter
+httpc_attempts_total{host="HOST",method="POST",route="/greet",status="200"} 1
+httpc_attempts_total{host="HOST",method="POST",route="/greet",status="503"} 1
+# HELP httpc_request_bytes_total Request body bytes sent.
+# TYPE httpc_request_bytes_total counter
+httpc_request_bytes_total{host="HOST",method="POST",route="/greet"} 4
+# HELP httpc_requests_in_flight Calls in flight.
+# TYPE httpc_requests_in_flight gauge
+httpc_requests_in_flight{host="HOST",method="POST",route="/greet"} 0
+# HELP httpc_requests_total Calls made, including their retries.
+# TYPE httpc_requests_total counter
+httpc_requests_total{host="HOST",method="POST",route="/greet",status="200"} 1
+# HELP httpc_response_bytes_total Response body bytes received.
+# TYPE httpc_response_bytes_total counter
+httpc_response_bytes_total{host="HOST",method="POST",route="/greet"} 5
+# HELP httpc_retries_total Attempts retrying a failed attempt.
+# TYPE httpc_retries_total counter
+httpc_retries_total{host="HOST",method="POST",route="/greet"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(strings.ReplaceAll(expected, "HOST", host)),
+		"httpc_attempts_total",
+		"httpc_request_bytes_total",
+		"httpc_requests_in_flight",
+		"httpc_requests_total",
+		"httpc_response_bytes_total",
+		"httpc_retries_total",
+	))
+	assert.Equal(t, 1, testutil.CollectAndCount(recorder, "httpc_request_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(recorder, "httpc_attempt_duration_seconds"))
+}
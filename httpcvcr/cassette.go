@@ -0,0 +1,133 @@
+// Package httpcvcr records the HTTP interactions of a client in a cassette
+// file and replays them, so tests run offline and deterministically.
+//
+//	recorder, err := httpcvcr.New("testdata/users.yaml", httpcvcr.ModeReplayOrRecord)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer recorder.Save()
+//	client := httpc.NewHttpClient(recorder.ClientOption())
+package httpcvcr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette holds the recorded interactions, in the order they happened.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+
+	replayed bool
+}
+
+// Request is a recorded request, its secrets already scrubbed.
+type Request struct {
+	Method  string      `json:"method" yaml:"method"`
+	URL     string      `json:"url" yaml:"url"`
+	Headers http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string      `json:"body,omitempty" yaml:"body,omitempty"`
+	// BodyEncoding is "base64" for binary bodies.
+	BodyEncoding string `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// Response is a recorded response, its secrets already scrubbed.
+type Response struct {
+	Status       int         `json:"status" yaml:"status"`
+	Headers      http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body         string      `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// Load reads a cassette, in JSON when path ends in ".json" and in YAML
+// otherwise.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := &Cassette{}
+	if isJSON(path) {
+		err = json.Unmarshal(data, cassette)
+	} else {
+		err = yaml.Unmarshal(data, cassette)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette, in JSON when path ends in ".json" and in YAML
+// otherwise. Missing directories are created.
+func (c *Cassette) Save(path string) error {
+	var data []byte
+	if isJSON(path) {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	} else {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+		if err := encoder.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// encodeBody returns body as text, binary bodies are base64 encoded.
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) && bytes.IndexByte(body, 0) < 0 {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, fmt.Errorf("unknown body encoding %q", encoding)
+	}
+}
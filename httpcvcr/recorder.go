@@ -0,0 +1,322 @@
+package httpcvcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+
+	httpc "github.com/thiagozs/go-httpc"
+)
+
+// ErrInteractionNotFound is returned in replay mode for requests missing from
+// the cassette.
+var ErrInteractionNotFound = errors.New("httpcvcr: interaction not found in cassette")
+
+// Mode tells the recorder whether to use the network.
+type Mode int
+
+const (
+	// ModeReplay answers from the cassette only, requests missing from it
+	// fail with ErrInteractionNotFound.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and records it in a new cassette.
+	ModeRecord
+	// ModeReplayOrRecord replays the requests found in the cassette and
+	// sends and records the others.
+	ModeReplayOrRecord
+)
+
+// Matcher reports whether a request matches a recorded one. The request is
+// scrubbed like the recorded ones before it is matched.
+type Matcher func(req, recorded *Request) bool
+
+// DefaultMatchers match requests on their method and URL.
+var DefaultMatchers = []Matcher{MatchMethod, MatchURL}
+
+// MatchMethod matches requests with the same method.
+func MatchMethod(req, recorded *Request) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchURL matches requests with the same URL, the order of the query
+// parameters does not matter.
+func MatchURL(req, recorded *Request) bool {
+	u1, err1 := url.Parse(req.URL)
+	u2, err2 := url.Parse(recorded.URL)
+	if err1 != nil || err2 != nil {
+		return req.URL == recorded.URL
+	}
+	q1, q2 := u1.Query(), u2.Query()
+	u1.RawQuery, u2.RawQuery = "", ""
+	return u1.String() == u2.String() && reflect.DeepEqual(q1, q2)
+}
+
+// MatchBody matches requests with the same body, JSON bodies are compared by
+// value.
+func MatchBody(req, recorded *Request) bool {
+	if req.Body == recorded.Body && req.BodyEncoding == recorded.BodyEncoding {
+		return true
+	}
+	if req.BodyEncoding != "" || recorded.BodyEncoding != "" {
+		return false
+	}
+	var v1, v2 interface{}
+	if json.Unmarshal([]byte(req.Body), &v1) != nil || json.Unmarshal([]byte(recorded.Body), &v2) != nil {
+		return false
+	}
+	return reflect.DeepEqual(v1, v2)
+}
+
+// MatchHeaders matches requests with the same values for the named headers.
+func MatchHeaders(names ...string) Matcher {
+	return func(req, recorded *Request) bool {
+		for _, name := range names {
+			if !reflect.DeepEqual(req.Headers.Values(name), recorded.Headers.Values(name)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type config struct {
+	matchers []Matcher
+	redactor *httpc.Redactor
+}
+
+// Option configures the recorder.
+type Option func(*config)
+
+// WithMatchers sets the matchers a recorded request must all pass to be
+// replayed, DefaultMatchers by default.
+func WithMatchers(matchers ...Matcher) Option {
+	return func(c *config) {
+		c.matchers = matchers
+	}
+}
+
+// WithRedactor sets the secrets scrubbed from the interactions before they
+// are recorded. The Authorization, Proxy-Authorization, Cookie and
+// Set-Cookie headers and the URL password are always scrubbed.
+func WithRedactor(redactor httpc.Redactor) Option {
+	return func(c *config) {
+		c.redactor = &redactor
+	}
+}
+
+// Recorder records the interactions of a client in a cassette and replays
+// them. Each recorded interaction is replayed once, in the order it was
+// recorded, so retries and repeated calls replay their own responses.
+type Recorder struct {
+	path     string
+	mode     Mode
+	matchers []Matcher
+	redactor *httpc.Redactor
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// New returns a recorder of the cassette at path. ModeReplay requires the
+// cassette to exist, ModeReplayOrRecord loads it when it exists and
+// ModeRecord starts a new one.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	cfg := &config{matchers: DefaultMatchers, redactor: &httpc.Redactor{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	cassette := &Cassette{}
+	switch mode {
+	case ModeReplay, ModeReplayOrRecord:
+		loaded, err := Load(path)
+		switch {
+		case err == nil:
+			cassette = loaded
+		case mode == ModeReplayOrRecord && errors.Is(err, os.ErrNotExist):
+		default:
+			return nil, err
+		}
+	case ModeRecord:
+	default:
+		return nil, fmt.Errorf("httpcvcr: unknown mode %d", mode)
+	}
+
+	return &Recorder{
+		path:     path,
+		mode:     mode,
+		matchers: cfg.matchers,
+		redactor: cfg.redactor,
+		cassette: cassette,
+	}, nil
+}
+
+// Mode returns the recorder mode.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Transport wraps next, http.DefaultTransport when nil, with the recorder.
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{recorder: r, next: next}
+}
+
+// ClientOption installs the recorder in the client transport.
+func (r *Recorder) ClientOption() httpc.HttpClientOptions {
+	return httpc.WithTransportMiddleware(r.Transport)
+}
+
+// Save writes the cassette with the recorded interactions. It does nothing in
+// replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// replay returns the first interaction matching req not replayed yet.
+func (r *Recorder) replay(req *Request) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, interaction := range r.cassette.Interactions {
+		if !interaction.replayed && r.matches(req, &interaction.Request) {
+			interaction.replayed = true
+			return interaction
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) matches(req, recorded *Request) bool {
+	for _, match := range r.matchers {
+		if !match(req, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) record(interaction *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// recorded interactions are not replayed by later requests of the run
+	interaction.replayed = true
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+}
+
+func (r *Recorder) request(req *http.Request, body []byte) Request {
+	recorded := Request{
+		Method:  req.Method,
+		URL:     r.redactor.URL(req.URL.String()),
+		Headers: r.redactor.Header(req.Header),
+	}
+	recorded.Body, recorded.BodyEncoding = r.body(req.Header, body)
+	return recorded
+}
+
+func (r *Recorder) response(resp *http.Response, body []byte) Response {
+	recorded := Response{
+		Status:  resp.StatusCode,
+		Headers: r.redactor.Header(resp.Header),
+	}
+	recorded.Body, recorded.BodyEncoding = r.body(resp.Header, body)
+	return recorded
+}
+
+func (r *Recorder) body(header http.Header, body []byte) (string, string) {
+	text, encoding := encodeBody(body)
+	if encoding != "" {
+		return text, encoding
+	}
+	return string(r.redactor.Body(header.Get("Content-Type"), body)), ""
+}
+
+type transport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recorded := t.recorder.request(req, body)
+	if t.recorder.mode != ModeRecord {
+		if interaction := t.recorder.replay(&recorded); interaction != nil {
+			return interaction.Response.httpResponse(req)
+		}
+		if t.recorder.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, recorded.Method, recorded.URL)
+		}
+	}
+
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.Request = req
+
+	t.recorder.record(&Interaction{
+		Request:  recorded,
+		Response: t.recorder.response(resp, respBody),
+	})
+	return resp, nil
+}
+
+func (r *Response) httpResponse(req *http.Request) (*http.Response, error) {
+	body, err := decodeBody(r.Body, r.BodyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("httpcvcr: replaying %s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	header := r.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	// scrubbing may have changed the body length
+	if header.Get("Content-Length") != "" {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
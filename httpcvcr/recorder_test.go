@@ -0,0 +1,195 @@
+package httpcvcr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpc "github.com/thiagozs/go-httpc"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hit":` + string(rune('0'+n)) + `}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "users.yaml")
+	recorder, err := New(path, ModeRecord)
+	require.NoError(t, err)
+	client := httpc.NewHttpClient(recorder.ClientOption())
+	for i := 0; i < 2; i++ {
+		_, _, err = client.Get(ts.URL + "/users")
+		require.NoError(t, err)
+	}
+	require.NoError(t, recorder.Save())
+	assert.EqualValues(t, 2, hits)
+
+	// the server is gone, the cassette answers in order
+	ts.Close()
+	recorder, err = New(path, ModeReplay)
+	require.NoError(t, err)
+	client = httpc.NewHttpClient(recorder.ClientOption())
+	resp, body, err := client.Get(ts.URL + "/users")
+	require.NoError(t, err)
+	assert.Equal(t, `{"hit":1}`, string(body))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	_, body, err = client.Get(ts.URL + "/users")
+	require.NoError(t, err)
+	assert.Equal(t, `{"hit":2}`, string(body))
+
+	_, _, err = client.Get(ts.URL + "/users")
+	assert.ErrorIs(t, err, ErrInteractionNotFound)
+}
+
+func TestRecorder_ReplayMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.yaml"), ModeReplay)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRecorder_ReplayOrRecord(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "hybrid.json")
+	recorder, err := New(path, ModeReplayOrRecord)
+	require.NoError(t, err)
+	client := httpc.NewHttpClient(recorder.ClientOption())
+	_, _, err = client.Get(ts.URL + "/a")
+	require.NoError(t, err)
+	require.NoError(t, recorder.Save())
+
+	recorder, err = New(path, ModeReplayOrRecord)
+	require.NoError(t, err)
+	client = httpc.NewHttpClient(recorder.ClientOption())
+	_, body, err := client.Get(ts.URL + "/a")
+	require.NoError(t, err)
+	assert.Equal(t, "/a", string(body))
+	_, body, err = client.Get(ts.URL + "/b")
+	require.NoError(t, err)
+	assert.Equal(t, "/b", string(body))
+	assert.EqualValues(t, 2, hits)
+	require.NoError(t, recorder.Save())
+
+	cassette, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cassette.Interactions, 2)
+	assert.Equal(t, ts.URL+"/b", cassette.Interactions[1].Request.URL)
+}
+
+func TestRecorder_ScrubsSecrets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token":"t0ken","name":"ana"}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	redactor := httpc.Redactor{Headers: []string{"X-Api-Key"}, QueryParams: []string{"key"}, JSONFields: []string{"token", "password"}}
+	recorder, err := New(path, ModeRecord, WithRedactor(redactor))
+	require.NoError(t, err)
+	client := httpc.NewHttpClient(recorder.ClientOption())
+	client.SetBasicAuth(http.MethodPost, "user", "pa55")
+	_, body, err := client.Do(context.Background(), &httpc.Request{
+		Method: http.MethodPost,
+		URL:    ts.URL + "/login?key=q5ecret",
+		Header: http.Header{"X-Api-Key": {"k3y"}, "Content-Type": {"application/json"}},
+		Body:   []byte(`{"password":"pa55w0rd"}`),
+	})
+	require.NoError(t, err)
+	// the caller still sees the real response
+	assert.Contains(t, string(body), "t0ken")
+	require.NoError(t, recorder.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{"k3y", "pa55", "q5ecret", "pa55w0rd", "t0ken", "s3cr3t"} {
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Contains(t, string(data), httpc.RedactedValue)
+	assert.Contains(t, string(data), "ana")
+
+	// requests are scrubbed before matching, so they still replay
+	recorder, err = New(path, ModeReplay, WithRedactor(redactor))
+	require.NoError(t, err)
+	client = httpc.NewHttpClient(recorder.ClientOption())
+	_, body, err = client.Do(context.Background(), &httpc.Request{
+		Method: http.MethodPost,
+		URL:    ts.URL + "/login?key=other",
+		Header: http.Header{"X-Api-Key": {"other"}, "Content-Type": {"application/json"}},
+		Body:   []byte(`{"password":"other"}`),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"token":"[REDACTED]","name":"ana"}`, string(body))
+}
+
+func TestRecorder_Matchers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Tenant")))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "matchers.yaml")
+	recorder, err := New(path, ModeRecord)
+	require.NoError(t, err)
+	client := httpc.NewHttpClient(recorder.ClientOption())
+	for _, tenant := range []string{"a", "b"} {
+		client.SetHeader(http.MethodPost, "X-Tenant", tenant)
+		_, _, err = client.Post(ts.URL+"/q?x=1&y=2", []byte(`{"a":1,"b":2}`))
+		require.NoError(t, err)
+	}
+	require.NoError(t, recorder.Save())
+
+	recorder, err = New(path, ModeReplay, WithMatchers(MatchMethod, MatchURL, MatchBody, MatchHeaders("X-Tenant")))
+	require.NoError(t, err)
+	client = httpc.NewHttpClient(recorder.ClientOption())
+	client.SetHeader(http.MethodPost, "X-Tenant", "b")
+	_, body, err := client.Post(ts.URL+"/q?y=2&x=1", []byte(`{"b":2,"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(body))
+
+	_, _, err = client.Post(ts.URL+"/q?y=2&x=1", []byte(`{"a":2}`))
+	assert.ErrorIs(t, err, ErrInteractionNotFound)
+	_, _, err = client.Get(ts.URL + "/q?y=2&x=1")
+	assert.ErrorIs(t, err, ErrInteractionNotFound)
+}
+
+func TestRecorder_BinaryBody(t *testing.T) {
+	payload := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(payload)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "binary.json")
+	recorder, err := New(path, ModeRecord)
+	require.NoError(t, err)
+	_, _, err = httpc.NewHttpClient(recorder.ClientOption()).Get(ts.URL)
+	require.NoError(t, err)
+	require.NoError(t, recorder.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"body_encoding": "base64"`)
+
+	recorder, err = New(path, ModeReplay)
+	require.NoError(t, err)
+	_, body, err := httpc.NewHttpClient(recorder.ClientOption()).Get(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, payload, body)
+}
@@ -0,0 +1,84 @@
+package httpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// Instrumentation observes the calls of a client, e.g. to trace or measure
+// them. StartCall and StartAttempt return the context used for the rest of
+// the call or attempt, so spans can be carried to the matching End method.
+// StartAttempt may also add headers to info.Request, they are set before the
+// request is signed.
+type Instrumentation interface {
+	StartCall(ctx context.Context, call CallInfo) context.Context
+	StartAttempt(ctx context.Context, info AttemptInfo) context.Context
+	EndAttempt(ctx context.Context, info ResponseInfo)
+	EndCall(ctx context.Context, call CallInfo, resp *http.Response, err error)
+}
+
+type routeKey struct{}
+
+// WithRouteTemplate attaches the route template of a call, such as
+// "/users/{id}", to ctx. Instrumentations use it instead of the URL to keep
+// the number of span names and metric labels low.
+func WithRouteTemplate(ctx context.Context, template string) context.Context {
+	return context.WithValue(ctx, routeKey{}, template)
+}
+
+// RouteTemplate returns the route template attached to ctx, if any.
+func RouteTemplate(ctx context.Context) string {
+	template, _ := ctx.Value(routeKey{}).(string)
+	return template
+}
+
+// WithInstrumentation adds inst to the client. Instrumentations are started
+// in the order they are added and ended in reverse order.
+func WithInstrumentation(inst Instrumentation) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if inst != nil {
+			s.Instrumentations = append(s.Instrumentations, inst)
+		}
+	}
+}
+
+func (c *HttpClient) instrumentations() []Instrumentation {
+	if c.params == nil {
+		return nil
+	}
+	return c.params.Instrumentations
+}
+
+func (c *HttpClient) startCall(ctx context.Context, call CallInfo) context.Context {
+	for _, inst := range c.instrumentations() {
+		ctx = inst.StartCall(ctx, call)
+	}
+	return ctx
+}
+
+func (c *HttpClient) endCall(ctx context.Context, call CallInfo, resp *http.Response, err error) {
+	insts := c.instrumentations()
+	for i := len(insts) - 1; i >= 0; i-- {
+		insts[i].EndCall(ctx, call, resp, err)
+	}
+}
+
+// startAttempt returns req with the context of the instrumentations.
+func (c *HttpClient) startAttempt(req *http.Request, info AttemptInfo) *http.Request {
+	insts := c.instrumentations()
+	if len(insts) == 0 {
+		return req
+	}
+	ctx := req.Context()
+	for _, inst := range insts {
+		ctx = inst.StartAttempt(ctx, info)
+	}
+	return req.WithContext(ctx)
+}
+
+func (c *HttpClient) endAttempt(ctx context.Context, info ResponseInfo) {
+	insts := c.instrumentations()
+	for i := len(insts) - 1; i >= 0; i-- {
+		insts[i].EndAttempt(ctx, info)
+	}
+}
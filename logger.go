@@ -0,0 +1,188 @@
+package httpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogLevel is the severity of a log record, with the values of the log/slog
+// levels.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// LogField is a key-value pair of a log record.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives the log records of a client. NewSlogLogger adapts a
+// log/slog handler and NewStdLogger a standard library *log.Logger, for Go
+// versions without log/slog.
+type Logger interface {
+	Enabled(ctx context.Context, level LogLevel) bool
+	Log(ctx context.Context, level LogLevel, msg string, fields ...LogField)
+}
+
+// WithLogger logs every attempt of the client calls with the method, URL,
+// attempt number, status and duration. The URL and headers are masked by the
+// client redactor, see WithRedactor.
+func WithLogger(logger Logger) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.Logger = logger
+	}
+}
+
+// WithLogLevels sets the levels of the attempts that succeed and of those that
+// fail, LevelInfo and LevelWarn by default.
+func WithLogLevels(success, failure LogLevel) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.LogSuccessLevel = success
+		s.LogFailureLevel = failure
+	}
+}
+
+// WithLogHeaders adds the request headers to the attempt logs.
+func WithLogHeaders(enabled bool) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.LogHeaders = enabled
+	}
+}
+
+// WithLogBodies adds the request bodies, truncated to limit bytes, to the
+// attempt logs. JSON bodies are masked by the client redactor.
+func WithLogBodies(limit int) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.LogBodyLimit = limit
+	}
+}
+
+func (c *HttpClient) logAttempt(ctx context.Context, info ResponseInfo, success bool) {
+	if c.params == nil || c.params.Logger == nil {
+		return
+	}
+	level := c.params.LogSuccessLevel
+	if !success {
+		level = c.params.LogFailureLevel
+	}
+	if !c.params.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	redactor := c.redactor()
+	fields := []LogField{
+		{Key: "method", Value: info.Method},
+		{Key: "url", Value: redactor.URL(info.URL)},
+		{Key: "attempt", Value: info.Attempt},
+	}
+	if info.Route != "" {
+		fields = append(fields, LogField{Key: "route", Value: info.Route})
+	}
+	if info.Response != nil {
+		fields = append(fields, LogField{Key: "status", Value: info.Response.StatusCode})
+	}
+	fields = append(fields, LogField{Key: "duration", Value: info.Duration})
+	if info.Err != nil {
+		fields = append(fields, LogField{Key: "error", Value: info.Err.Error()})
+	}
+	if c.params.LogHeaders && info.Request != nil {
+		fields = append(fields, LogField{Key: "headers", Value: redactor.Header(info.Request.Header)})
+	}
+	if c.params.LogBodyLimit > 0 && info.Request != nil {
+		if body, err := requestPayload(info.Request); err == nil && len(body) > 0 {
+			body = redactor.Body(info.Request.Header.Get("Content-Type"), body)
+			if len(body) > c.params.LogBodyLimit {
+				body = body[:c.params.LogBodyLimit]
+			}
+			fields = append(fields, LogField{Key: "body", Value: string(body)})
+		}
+	}
+
+	msg := "http request"
+	if !success {
+		msg = "http request failed"
+	}
+	c.params.Logger.Log(ctx, level, msg, fields...)
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+// NewStdLogger returns a Logger writing the records of level and above to
+// logger as "LEVEL msg key=value ..." lines.
+func NewStdLogger(logger *log.Logger, level LogLevel) Logger {
+	return &stdLogger{logger: logger, level: level}
+}
+
+func (l *stdLogger) Enabled(_ context.Context, level LogLevel) bool {
+	return level >= l.level
+}
+
+func (l *stdLogger) Log(_ context.Context, level LogLevel, msg string, fields ...LogField) {
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for _, field := range fields {
+		b.WriteString(" ")
+		b.WriteString(field.Key)
+		b.WriteString("=")
+		b.WriteString(formatLogValue(field.Value))
+	}
+	l.logger.Print(b.String())
+}
+
+func formatLogValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if strings.ContainsAny(v, " \"=") || v == "" {
+			return fmt.Sprintf("%q", v)
+		}
+		return v
+	case time.Duration:
+		return v.String()
+	case http.Header:
+		return formatHeaderValue(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func formatHeaderValue(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+":"+strings.Join(header[name], ","))
+	}
+	return fmt.Sprintf("%q", strings.Join(parts, " "))
+}
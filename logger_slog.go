@@ -0,0 +1,31 @@
+//go:build go1.21
+
+package httpc
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogLogger struct {
+	handler slog.Handler
+}
+
+// NewSlogLogger returns a Logger writing to a log/slog handler, such as
+// slog.Default().Handler().
+func NewSlogLogger(handler slog.Handler) Logger {
+	return &slogLogger{handler: handler}
+}
+
+func (l *slogLogger) Enabled(ctx context.Context, level LogLevel) bool {
+	return l.handler.Enabled(ctx, slog.Level(level))
+}
+
+func (l *slogLogger) Log(ctx context.Context, level LogLevel, msg string, fields ...LogField) {
+	logger := slog.New(l.handler)
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	logger.LogAttrs(ctx, slog.Level(level), msg, attrs...)
+}
@@ -0,0 +1,31 @@
+//go:build go1.21
+
+package httpc
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	handler := slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})
+	client := NewHttpClient(
+		WithLogger(NewSlogLogger(handler)),
+		WithLogLevels(LevelDebug, LevelError),
+	)
+
+	_, _, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "level=DEBUG msg=\"http request\" method=GET url="+ts.URL+" attempt=1 status=200")
+}
@@ -0,0 +1,113 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedLog struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type fakeLogger struct {
+	records []recordedLog
+}
+
+func (l *fakeLogger) Enabled(context.Context, LogLevel) bool { return true }
+
+func (l *fakeLogger) Log(_ context.Context, level LogLevel, msg string, fields ...LogField) {
+	record := recordedLog{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, field := range fields {
+		record.fields[field.Key] = field.Value
+	}
+	l.records = append(l.records, record)
+}
+
+func TestHttpClient_Logger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	logger := &fakeLogger{}
+	client := NewHttpClient(
+		WithLogger(logger),
+		WithLogHeaders(true),
+		WithLogBodies(1024),
+		WithRedactor(Redactor{QueryParams: []string{"key"}, JSONFields: []string{"password"}}),
+	)
+	client.SetBasicAuth(http.MethodPost, "ana", "s3cr3t")
+
+	_, _, err := client.Post(ts.URL+"/login?key=abc", []byte(`{"user":"ana","password":"s3cr3t"}`))
+	assert.NoError(t, err)
+	_, _, err = client.Get(ts.URL + "/fail")
+	assert.Error(t, err)
+
+	if !assert.Len(t, logger.records, 2) {
+		return
+	}
+	success := logger.records[0]
+	assert.Equal(t, LevelInfo, success.level)
+	assert.Equal(t, http.MethodPost, success.fields["method"])
+	assert.Equal(t, ts.URL+"/login?key=[REDACTED]", success.fields["url"])
+	assert.Equal(t, 1, success.fields["attempt"])
+	assert.Equal(t, http.StatusOK, success.fields["status"])
+	assert.Contains(t, success.fields, "duration")
+	assert.Equal(t, []string{RedactedValue}, success.fields["headers"].(http.Header)["Authorization"])
+	assert.JSONEq(t, `{"user":"ana","password":"[REDACTED]"}`, success.fields["body"].(string))
+
+	failure := logger.records[1]
+	assert.Equal(t, LevelWarn, failure.level)
+	assert.Equal(t, "http request failed", failure.msg)
+	assert.Equal(t, http.StatusBadRequest, failure.fields["status"])
+}
+
+func TestHttpClient_LoggerRedactsForms(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	logger := &fakeLogger{}
+	client := NewHttpClient(
+		WithLogger(logger),
+		WithLogBodies(1024),
+		WithRedactor(Redactor{JSONFields: []string{"password"}}),
+	)
+	_, _, err := client.PostForm(ts.URL, url.Values{"user": {"ana"}, "password": {"hunter2"}})
+	assert.NoError(t, err)
+
+	if !assert.Len(t, logger.records, 1) {
+		return
+	}
+	assert.Equal(t, "password=[REDACTED]&user=ana", logger.records[0].fields["body"])
+}
+
+func TestStdLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewStdLogger(log.New(&out, "", 0), LevelInfo)
+	assert.False(t, logger.Enabled(context.Background(), LevelDebug))
+
+	logger.Log(context.Background(), LevelWarn, "http request failed",
+		LogField{Key: "method", Value: "GET"},
+		LogField{Key: "error", Value: "connection refused"},
+		LogField{Key: "headers", Value: http.Header{"Authorization": {RedactedValue}}},
+	)
+	assert.Equal(t,
+		`WARN http request failed method=GET error="connection refused" headers="Authorization:[REDACTED]"`,
+		strings.TrimSpace(out.String()))
+}
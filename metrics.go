@@ -0,0 +1,120 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MetricLabels identify the series a call or attempt is recorded in. Route is
+// the template set with WithRouteTemplate, empty when not set.
+type MetricLabels struct {
+	Host   string
+	Method string
+	Route  string
+}
+
+// MetricsRecorder receives the measurements of the calls of a client. status
+// is the response status code, 0 when no response was received. See the
+// httpcprom package for a Prometheus implementation.
+type MetricsRecorder interface {
+	// CallStarted and CallFinished are called once per call, the calls in
+	// flight are the started calls not finished yet.
+	CallStarted(labels MetricLabels)
+	CallFinished(labels MetricLabels, status int, duration time.Duration)
+	AttemptFinished(labels MetricLabels, status int, duration time.Duration)
+	Retried(labels MetricLabels)
+	BytesSent(labels MetricLabels, n int64)
+	BytesReceived(labels MetricLabels, n int64)
+}
+
+// WithMetrics records the calls of the client in recorder.
+func WithMetrics(recorder MetricsRecorder) HttpClientOptions {
+	if recorder == nil {
+		return func(*HttpClientParams) {}
+	}
+	return WithInstrumentation(&metricsInstrumentation{recorder: recorder})
+}
+
+type metricsInstrumentation struct {
+	recorder MetricsRecorder
+}
+
+func (m *metricsInstrumentation) StartCall(ctx context.Context, call CallInfo) context.Context {
+	m.recorder.CallStarted(metricLabels(call.Method, call.URL, call.Route))
+	return ctx
+}
+
+func (m *metricsInstrumentation) EndCall(ctx context.Context, call CallInfo, resp *http.Response, err error) {
+	var status int
+	var statusErr *StatusError
+	var retryErr *RetryError
+	switch {
+	case resp != nil:
+		status = resp.StatusCode
+	case errors.As(err, &retryErr) && errors.As(retryErr.Last(), &statusErr):
+		status = statusErr.StatusCode
+	}
+	m.recorder.CallFinished(metricLabels(call.Method, call.URL, call.Route), status, call.Duration)
+}
+
+func (m *metricsInstrumentation) StartAttempt(ctx context.Context, info AttemptInfo) context.Context {
+	if info.Attempt > 1 {
+		m.recorder.Retried(metricLabels(info.Method, info.URL, info.Route))
+	}
+	return ctx
+}
+
+func (m *metricsInstrumentation) EndAttempt(ctx context.Context, info ResponseInfo) {
+	labels := metricLabels(info.Method, info.URL, info.Route)
+	if info.Request != nil && info.Request.ContentLength > 0 {
+		m.recorder.BytesSent(labels, info.Request.ContentLength)
+	}
+
+	var status int
+	if info.Response != nil {
+		status = info.Response.StatusCode
+		info.Response.Body = &countingBody{
+			ReadCloser: info.Response.Body,
+			report: func(n int64) {
+				m.recorder.BytesReceived(labels, n)
+			},
+		}
+	}
+	m.recorder.AttemptFinished(labels, status, info.Duration)
+}
+
+func metricLabels(method, addrs, route string) MetricLabels {
+	labels := MetricLabels{Method: method, Route: route}
+	if u, err := url.Parse(addrs); err == nil {
+		labels.Host = strings.ToLower(u.Host)
+	}
+	return labels
+}
+
+// countingBody reports the bytes read from a response body once it is closed.
+type countingBody struct {
+	io.ReadCloser
+	n      int64
+	closed int32
+	report func(int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(&b.n, int64(n))
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if atomic.CompareAndSwapInt32(&b.closed, 0, 1) {
+		b.report(atomic.LoadInt64(&b.n))
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package httpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRecorder struct {
+	sync.Mutex
+	inFlight      int
+	calls         []int
+	attempts      []int
+	retries       int
+	bytesSent     int64
+	bytesReceived int64
+	labels        MetricLabels
+}
+
+func (r *fakeRecorder) CallStarted(labels MetricLabels) {
+	r.Lock()
+	defer r.Unlock()
+	r.inFlight++
+	r.labels = labels
+}
+
+func (r *fakeRecorder) CallFinished(labels MetricLabels, status int, duration time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+	r.inFlight--
+	r.calls = append(r.calls, status)
+}
+
+func (r *fakeRecorder) AttemptFinished(labels MetricLabels, status int, duration time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+	r.attempts = append(r.attempts, status)
+}
+
+func (r *fakeRecorder) Retried(labels MetricLabels) {
+	r.Lock()
+	defer r.Unlock()
+	r.retries++
+}
+
+func (r *fakeRecorder) BytesSent(labels MetricLabels, n int64) {
+	r.Lock()
+	defer r.Unlock()
+	r.bytesSent += n
+}
+
+func (r *fakeRecorder) BytesReceived(labels MetricLabels, n int64) {
+	r.Lock()
+	defer r.Unlock()
+	r.bytesReceived += n
+}
+
+func TestHttpClient_Metrics(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("down"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	recorder := &fakeRecorder{}
+	client := NewHttpClient(
+		WithMetrics(recorder),
+		WithRetryStatusCodes(http.StatusBadGateway),
+		WithMaxRetryWait(0),
+	)
+
+	ctx := WithRouteTemplate(context.Background(), "/items/{id}")
+	_, _, err := client.PostWithContext(ctx, ts.URL+"/items/1", []byte(`{"a":1}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, recorder.inFlight)
+	assert.Equal(t, []int{http.StatusOK}, recorder.calls)
+	assert.Equal(t, []int{http.StatusBadGateway, http.StatusOK}, recorder.attempts)
+	assert.Equal(t, 1, recorder.retries)
+	assert.EqualValues(t, 14, recorder.bytesSent)
+	assert.EqualValues(t, 6, recorder.bytesReceived)
+	assert.Equal(t, http.MethodPost, recorder.labels.Method)
+	assert.Equal(t, "/items/{id}", recorder.labels.Route)
+	assert.Equal(t, ts.Listener.Addr().String(), recorder.labels.Host)
+}
+
+func TestHttpClient_MetricsTransportError(t *testing.T) {
+	recorder := &fakeRecorder{}
+	client := NewHttpClient(WithMetrics(recorder), WithMaxRetries(1))
+
+	_, _, err := client.Get("http://127.0.0.1:1")
+	assert.Error(t, err)
+	assert.Equal(t, []int{0}, recorder.calls)
+	assert.Equal(t, []int{0}, recorder.attempts)
+}
@@ -1,22 +1,91 @@
 package httpc
 
-import "net/http"
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
 
 type HttpClientParams struct {
-	MaxRetryWait     int
-	MaxRetries       int
-	RetryStatusCodes map[int]struct{}
-	MethodRetries    map[string]int
-	RequestHooks     []RequestHook
+	MaxRetryWait            int
+	MaxRetries              int
+	RetryStatusCodes        map[int]struct{}
+	MethodRetries           map[string]int
+	RequestHooks            []RequestHook
+	RequestSigner           RequestSigner
+	CookieJar               http.CookieJar
+	RedirectPolicy          *RedirectPolicy
+	ProxyURL                string
+	ProxyUsername           string
+	ProxyPassword           string
+	NoProxy                 []string
+	HostProxies             []HostProxy
+	ProxyFunc               ProxyFunc
+	UnixSockets             map[string]string
+	DialContext             DialContextFunc
+	HostOverrides           map[string][]string
+	Resolver                Resolver
+	DNSCacheTTL             time.Duration
+	IPPreference            IPPreference
+	FallbackDelay           time.Duration
+	Services                map[string]ServiceEndpoints
+	MaxEndpointFailures     int
+	EndpointEjectTime       time.Duration
+	BaseURL                 string
+	SuccessPredicate        SuccessPredicate
+	MethodSuccessPredicates map[string]SuccessPredicate
+	ResponseOnError         bool
+	AttemptTimeout          time.Duration
+	TotalTimeout            time.Duration
+	MethodAttemptTimeouts   map[string]time.Duration
+	MethodTotalTimeouts     map[string]time.Duration
+	MinRetryTime            time.Duration
+	RetryBudget             *RetryBudget
+	OnAttemptStart          []AttemptStartFunc
+	OnResponse              []ResponseFunc
+	OnRetry                 []RetryFunc
+	OnError                 []ErrorFunc
+	OnGiveUp                []GiveUpFunc
+	OnSuccess               []SuccessFunc
+	Instrumentations        []Instrumentation
+	Redactor                *Redactor
+	Logger                  Logger
+	LogSuccessLevel         LogLevel
+	LogFailureLevel         LogLevel
+	LogHeaders              bool
+	LogBodyLimit            int
+	Timings                 bool
+	Debug                   io.Writer
+	DebugBodyLimit          int
+	InsecureSkipVerify      bool
+	Transport               http.RoundTripper
+	TransportMiddlewares    []TransportMiddleware
+}
+
+// HostProxy routes the hosts matching Pattern through ProxyURL. Patterns use
+// the same syntax as the NO_PROXY environment variable.
+type HostProxy struct {
+	Pattern  string
+	ProxyURL string
 }
 
 type HttpClientOptions func(*HttpClientParams)
 type RequestHook func(*http.Request)
 
+// TransportMiddleware wraps the transport of the client, e.g. to record or
+// replay the traffic.
+type TransportMiddleware func(http.RoundTripper) http.RoundTripper
+
 func newHttpClientParams(opts ...HttpClientOptions) *HttpClientParams {
 	s := &HttpClientParams{
-		MaxRetryWait: 10,
-		MaxRetries:   3,
+		MaxRetryWait:    10,
+		MaxRetries:      3,
+		CookieJar:       NewCookieJar(),
+		LogSuccessLevel: LevelInfo,
+		LogFailureLevel: LevelWarn,
+		DebugBodyLimit:  defaultDebugBodyLimit,
+		Debug:           debugFromEnv(),
 	} //default values
 
 	for _, opt := range opts {
@@ -68,6 +137,263 @@ func WithRequestHook(hook RequestHook) HttpClientOptions {
 	}
 }
 
+func WithRequestSigner(signer RequestSigner) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.RequestSigner = signer
+	}
+}
+
+// WithCookieJar replaces the default in-memory jar. A nil jar disables
+// cookie handling.
+func WithCookieJar(jar http.CookieJar) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.CookieJar = jar
+	}
+}
+
+func WithRedirectPolicy(policy RedirectPolicy) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.RedirectPolicy = &policy
+	}
+}
+
+// WithProxy routes every request through proxyURL. The http, https, socks5
+// and socks5h schemes are supported.
+func WithProxy(proxyURL string) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.ProxyURL = proxyURL
+	}
+}
+
+// WithProxyAuth sets the credentials used with proxies whose URL has none.
+func WithProxyAuth(username, password string) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.ProxyUsername = username
+		s.ProxyPassword = password
+	}
+}
+
+// WithNoProxy lists hosts that bypass the proxies, in NO_PROXY syntax.
+func WithNoProxy(patterns ...string) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.NoProxy = append(s.NoProxy, patterns...)
+	}
+}
+
+func WithHostProxy(pattern, proxyURL string) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.HostProxies = append(s.HostProxies, HostProxy{Pattern: pattern, ProxyURL: proxyURL})
+	}
+}
+
+// WithProxyFunc selects the proxy per request, taking precedence over every
+// other proxy option.
+func WithProxyFunc(fn ProxyFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.ProxyFunc = fn
+	}
+}
+
+// WithUnixSocket dials socketPath for requests to host, e.g.
+// WithUnixSocket("docker", "/var/run/docker.sock") serves http://docker/....
+// On Linux a path starting with "@" names an abstract socket.
+func WithUnixSocket(host, socketPath string) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if s.UnixSockets == nil {
+			s.UnixSockets = make(map[string]string)
+		}
+		s.UnixSockets[strings.ToLower(host)] = socketPath
+	}
+}
+
+func WithDialContext(dial DialContextFunc) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.DialContext = dial
+	}
+}
+
+// WithHostOverride pins host to addrs, like curl --resolve. The host may
+// carry a port ("example.com:443") to only match that port, and addrs may
+// carry a port to also redirect the connection.
+func WithHostOverride(host string, addrs ...string) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if s.HostOverrides == nil {
+			s.HostOverrides = make(map[string][]string)
+		}
+		s.HostOverrides[strings.ToLower(host)] = append([]string(nil), addrs...)
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of server certificates,
+// like curl -k. Only use it against test servers.
+func WithInsecureSkipVerify(skip bool) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.InsecureSkipVerify = skip
+	}
+}
+
+// WithTransport replaces the network transport of the client, e.g. with a
+// mock in tests. The proxy, dialer, host override and TLS options configure
+// the default transport and have no effect on it.
+func WithTransport(transport http.RoundTripper) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.Transport = transport
+	}
+}
+
+// WithTransportMiddleware wraps the client transport with middleware. The
+// middleware added last is the outermost one.
+func WithTransportMiddleware(middleware TransportMiddleware) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if middleware != nil {
+			s.TransportMiddlewares = append(s.TransportMiddlewares, middleware)
+		}
+	}
+}
+
+func WithResolver(resolver Resolver) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.Resolver = resolver
+	}
+}
+
+// WithDNSCache caches lookups for at most ttl. Resolvers implementing
+// TTLResolver may shorten it with the record TTL.
+func WithDNSCache(ttl time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.DNSCacheTTL = ttl
+	}
+}
+
+func WithIPPreference(preference IPPreference) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.IPPreference = preference
+	}
+}
+
+// WithFallbackDelay sets how long the preferred address family is tried
+// before racing the other one (happy eyeballs).
+func WithFallbackDelay(delay time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.FallbackDelay = delay
+	}
+}
+
+// WithEndpoints balances the requests addressed to the logical host service,
+// e.g. http://users/v1/me, across endpoints. Retries fail over to another
+// endpoint when one is available.
+func WithEndpoints(service string, strategy BalanceStrategy, endpoints ...Endpoint) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if s.Services == nil {
+			s.Services = make(map[string]ServiceEndpoints)
+		}
+		s.Services[strings.ToLower(service)] = ServiceEndpoints{
+			Strategy:  strategy,
+			Endpoints: append([]Endpoint(nil), endpoints...),
+		}
+	}
+}
+
+// WithPassiveHealthCheck ejects an endpoint for ejectFor after maxFailures
+// consecutive failed attempts (transport errors or 5xx statuses).
+func WithPassiveHealthCheck(maxFailures int, ejectFor time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.MaxEndpointFailures = maxFailures
+		s.EndpointEjectTime = ejectFor
+	}
+}
+
+// WithBaseURL resolves relative addresses against baseURL, so
+// client.Get("/users/1") calls baseURL + "/users/1".
+func WithBaseURL(baseURL string) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.BaseURL = baseURL
+	}
+}
+
+// WithSuccessPredicate decides which responses are successful. By default
+// every status below 400 is.
+func WithSuccessPredicate(predicate SuccessPredicate) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.SuccessPredicate = predicate
+	}
+}
+
+func WithMethodSuccessPredicate(method string, predicate SuccessPredicate) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if s.MethodSuccessPredicates == nil {
+			s.MethodSuccessPredicates = make(map[string]SuccessPredicate)
+		}
+		s.MethodSuccessPredicates[methodKey(method)] = predicate
+	}
+}
+
+// WithErrorOnStatus only treats the listed statuses as errors.
+func WithErrorOnStatus(codes ...int) HttpClientOptions {
+	return WithSuccessPredicate(errorOnStatus(codes...))
+}
+
+func WithMethodErrorOnStatus(method string, codes ...int) HttpClientOptions {
+	return WithMethodSuccessPredicate(method, errorOnStatus(codes...))
+}
+
+// WithResponseOnError returns the response and its body together with the
+// *StatusError of unsuccessful responses, instead of nil values.
+func WithResponseOnError(enabled bool) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.ResponseOnError = enabled
+	}
+}
+
+// WithAttemptTimeout bounds each attempt, retries get a fresh timeout.
+func WithAttemptTimeout(timeout time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.AttemptTimeout = timeout
+	}
+}
+
+// WithTotalTimeout bounds the whole call, including every attempt and the
+// waits between them.
+func WithTotalTimeout(timeout time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.TotalTimeout = timeout
+	}
+}
+
+func WithMethodAttemptTimeout(method string, timeout time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if s.MethodAttemptTimeouts == nil {
+			s.MethodAttemptTimeouts = make(map[string]time.Duration)
+		}
+		s.MethodAttemptTimeouts[methodKey(method)] = timeout
+	}
+}
+
+func WithMethodTotalTimeout(method string, timeout time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		if s.MethodTotalTimeouts == nil {
+			s.MethodTotalTimeouts = make(map[string]time.Duration)
+		}
+		s.MethodTotalTimeouts[methodKey(method)] = timeout
+	}
+}
+
+// WithMinRetryTime skips a retry when, after the retry wait, less than
+// minimum is left before the call deadline.
+func WithMinRetryTime(minimum time.Duration) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.MinRetryTime = minimum
+	}
+}
+
+// WithRetryBudget shares a retry budget between the calls of the client, see
+// RetryBudget.
+func WithRetryBudget(budget RetryBudget) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.RetryBudget = &budget
+	}
+}
+
 // getters and setters -----
 
 func (s *HttpClientParams) GetMaxRetryWait() int {
@@ -103,6 +429,44 @@ func (s *HttpClientParams) GetRequestHooks() []RequestHook {
 	return clone
 }
 
+func (s *HttpClientParams) GetRequestSigner() RequestSigner {
+	return s.RequestSigner
+}
+
+func (s *HttpClientParams) GetCookieJar() http.CookieJar {
+	return s.CookieJar
+}
+
+func (s *HttpClientParams) GetRedirectPolicy() *RedirectPolicy {
+	if s.RedirectPolicy == nil {
+		return nil
+	}
+	policy := *s.RedirectPolicy
+	return &policy
+}
+
+func (s *HttpClientParams) GetBaseURL() string {
+	return s.BaseURL
+}
+
+func (s *HttpClientParams) GetProxyURL() string {
+	return s.ProxyURL
+}
+
+func (s *HttpClientParams) GetNoProxy() []string {
+	if len(s.NoProxy) == 0 {
+		return nil
+	}
+	return append([]string(nil), s.NoProxy...)
+}
+
+func (s *HttpClientParams) GetHostProxies() []HostProxy {
+	if len(s.HostProxies) == 0 {
+		return nil
+	}
+	return append([]HostProxy(nil), s.HostProxies...)
+}
+
 func cloneIntSet(values map[int]struct{}) map[int]struct{} {
 	if len(values) == 0 {
 		return nil
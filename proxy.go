@@ -0,0 +1,132 @@
+package httpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// environmentProxy reads HTTP_PROXY, HTTPS_PROXY and NO_PROXY, it is
+// replaced in tests.
+var environmentProxy = http.ProxyFromEnvironment
+
+// ProxyFunc selects the proxy for a request. A nil URL sends the request
+// directly.
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// proxyFunc resolves the proxy for each request in order: the proxy
+// selector, the no-proxy list, per-host proxies and the static proxy.
+// Without a static proxy the environment variables are honoured, after the
+// no-proxy list and with the proxy credentials. Hosts mapped to unix sockets
+// are never proxied.
+func (s *HttpClientParams) proxyFunc() ProxyFunc {
+	proxy := s.configuredProxyFunc()
+	if len(s.UnixSockets) == 0 {
+		return proxy
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if _, ok := s.unixSocketFor(req.URL.Host); ok {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+}
+
+// proxyConfigured reports whether a proxy option is set, without one the
+// default transport already honours the environment variables.
+func (s *HttpClientParams) proxyConfigured() bool {
+	return s.ProxyFunc != nil || s.ProxyURL != "" || len(s.HostProxies) > 0 ||
+		len(s.NoProxy) > 0 || s.ProxyUsername != ""
+}
+
+func (s *HttpClientParams) configuredProxyFunc() ProxyFunc {
+	if s.ProxyFunc != nil {
+		return s.ProxyFunc
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		for _, pattern := range s.NoProxy {
+			if matchHostPattern(pattern, req.URL) {
+				return nil, nil
+			}
+		}
+		for _, rule := range s.HostProxies {
+			if matchHostPattern(rule.Pattern, req.URL) {
+				return s.parseProxyURL(rule.ProxyURL)
+			}
+		}
+		if s.ProxyURL != "" {
+			return s.parseProxyURL(s.ProxyURL)
+		}
+		proxyURL, err := environmentProxy(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+		return s.withProxyAuth(proxyURL), nil
+	}
+}
+
+func (s *HttpClientParams) parseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", raw, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: unsupported scheme %q", raw, proxyURL.Scheme)
+	}
+	return s.withProxyAuth(proxyURL), nil
+}
+
+// withProxyAuth returns proxyURL with the proxy credentials unless it has its
+// own. proxyURL is copied, the environment proxy URL is shared by the whole
+// process.
+func (s *HttpClientParams) withProxyAuth(proxyURL *url.URL) *url.URL {
+	if proxyURL.User != nil || s.ProxyUsername == "" {
+		return proxyURL
+	}
+	withAuth := *proxyURL
+	withAuth.User = url.UserPassword(s.ProxyUsername, s.ProxyPassword)
+	return &withAuth
+}
+
+// matchHostPattern follows the NO_PROXY conventions: "*" matches every host,
+// "example.com" and ".example.com" match the domain and its subdomains, an
+// optional ":port" restricts the port and CIDR ranges match IP hosts.
+func matchHostPattern(pattern string, u *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return false
+	}
+	if pattern == "*" {
+		return true
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+
+	if h, port, err := net.SplitHostPort(pattern); err == nil {
+		if port != urlPort(u) {
+			return false
+		}
+		pattern = h
+	}
+	pattern = strings.TrimPrefix(pattern, "*")
+	pattern = strings.TrimPrefix(pattern, ".")
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
@@ -0,0 +1,161 @@
+package httpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces the redacted values.
+const RedactedValue = "[REDACTED]"
+
+// defaultRedactedHeaders are always redacted.
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Redactor masks secrets in the requests and responses that are logged,
+// dumped or recorded. Names are matched case-insensitively. The
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie headers and the
+// URL password are always redacted.
+type Redactor struct {
+	Headers     []string
+	QueryParams []string
+	// JSONFields are masked at any depth of JSON bodies.
+	JSONFields []string
+}
+
+// WithRedactor sets the secrets masked by the logger and the debug output.
+func WithRedactor(redactor Redactor) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.Redactor = &redactor
+	}
+}
+
+func (c *HttpClient) redactor() *Redactor {
+	if c.params == nil || c.params.Redactor == nil {
+		return &Redactor{}
+	}
+	return c.params.Redactor
+}
+
+// Header returns a copy of header with the redacted values masked.
+func (r *Redactor) Header(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if r.redactsHeader(name) {
+			masked := make([]string, len(values))
+			for i := range masked {
+				masked[i] = RedactedValue
+			}
+			redacted[name] = masked
+			continue
+		}
+		redacted[name] = append([]string(nil), values...)
+	}
+	return redacted
+}
+
+// URL masks the password and the redacted query parameters of addrs.
+func (r *Redactor) URL(addrs string) string {
+	u, err := url.Parse(addrs)
+	if err != nil {
+		return addrs
+	}
+	if len(r.QueryParams) > 0 && u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			key, _, _ := strings.Cut(param, "=")
+			if name, err := url.QueryUnescape(key); err == nil && containsFold(r.QueryParams, name) {
+				params[i] = key + "=" + RedactedValue
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.Redacted()
+}
+
+// JSON masks the redacted fields of a JSON body. Bodies that do not parse,
+// such as truncated ones, have the values of the fields masked in place.
+func (r *Redactor) JSON(body []byte) []byte {
+	if len(r.JSONFields) == 0 || len(bytes.TrimSpace(body)) == 0 {
+		return body
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return r.jsonFieldsPattern().ReplaceAll(body, []byte(`"$1":"`+RedactedValue+`"`))
+	}
+	redacted, err := json.Marshal(r.redactJSON(doc))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// Body masks the redacted fields of a form or JSON body, as told by
+// contentType.
+func (r *Redactor) Body(contentType string, body []byte) []byte {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == formContentType {
+		return []byte(r.formBody(string(body)))
+	}
+	return r.JSON(body)
+}
+
+// jsonFieldsPattern matches the redacted fields and their values, strings
+// may be cut by the end of the body.
+func (r *Redactor) jsonFieldsPattern() *regexp.Regexp {
+	names := make([]string, len(r.JSONFields))
+	for i, name := range r.JSONFields {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return regexp.MustCompile(`"((?i:` + strings.Join(names, "|") + `))"\s*:\s*(?:"(?:[^"\\]|\\.)*(?:"|\\?$)|[^,}\]\s]+)`)
+}
+
+// formBody masks the fields of a form body named in QueryParams or
+// JSONFields.
+func (r *Redactor) formBody(body string) string {
+	fields := strings.Split(body, "&")
+	for i, field := range fields {
+		key, _, _ := strings.Cut(field, "=")
+		name, err := url.QueryUnescape(key)
+		if err == nil && (containsFold(r.QueryParams, name) || containsFold(r.JSONFields, name)) {
+			fields[i] = key + "=" + RedactedValue
+		}
+	}
+	return strings.Join(fields, "&")
+}
+
+func (r *Redactor) redactJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if containsFold(r.JSONFields, key) {
+				value[key] = RedactedValue
+				continue
+			}
+			value[key] = r.redactJSON(field)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = r.redactJSON(item)
+		}
+	}
+	return v
+}
+
+func (r *Redactor) redactsHeader(name string) bool {
+	return containsFold(defaultRedactedHeaders, name) || containsFold(r.Headers, name)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
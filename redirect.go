@@ -0,0 +1,105 @@
+package httpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var ErrRedirectBlocked = errors.New("redirect blocked by policy")
+
+// RedirectPolicy controls how redirects are followed.
+type RedirectPolicy struct {
+	// Disable returns the redirect response itself instead of following it.
+	Disable bool
+	// MaxRedirects caps the number of redirects, 10 when zero.
+	MaxRedirects int
+	// SameHostOnly blocks redirects to a host other than the original one.
+	SameHostOnly bool
+	// HTTPSOnly blocks redirects to non-HTTPS URLs.
+	HTTPSOnly bool
+	// ForwardHeaders keeps the headers set with SetHeader on cross-host
+	// redirects. They are removed by default.
+	ForwardHeaders bool
+	// ForwardAuth keeps the credentials set with SetBasicAuth on cross-host
+	// redirects. They are removed by default.
+	ForwardAuth bool
+}
+
+// RedirectHop is a redirect response that was followed to reach the final
+// response.
+type RedirectHop struct {
+	URL        string
+	StatusCode int
+	Location   string
+}
+
+const defaultMaxRedirects = 10
+
+func (c *HttpClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	policy := c.params.RedirectPolicy
+	if policy.Disable {
+		return http.ErrUseLastResponse
+	}
+
+	maxRedirects := policy.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	if len(via) > maxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrRedirectBlocked, maxRedirects)
+	}
+
+	original := via[0]
+	if policy.HTTPSOnly && req.URL.Scheme != "https" {
+		return fmt.Errorf("%w: non-https redirect to %s", ErrRedirectBlocked, req.URL.Redacted())
+	}
+
+	if sameHost(original, req) {
+		return nil
+	}
+	if policy.SameHostOnly {
+		return fmt.Errorf("%w: cross-host redirect to %s", ErrRedirectBlocked, req.URL.Host)
+	}
+
+	if !policy.ForwardHeaders {
+		for key := range c.GetHeaders(original.Method) {
+			req.Header.Del(key)
+		}
+	}
+	if policy.ForwardAuth {
+		c.setBasicAuth(original.Method, req)
+	} else if len(c.GetBasicAuth(original.Method)) > 0 {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
+
+func sameHost(a, b *http.Request) bool {
+	return strings.EqualFold(a.URL.Host, b.URL.Host)
+}
+
+// RedirectChain returns the redirects followed to obtain resp, oldest first.
+func RedirectChain(resp *http.Response) []RedirectHop {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+
+	var hops []RedirectHop
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		hop := RedirectHop{
+			StatusCode: req.Response.StatusCode,
+			Location:   req.URL.String(),
+		}
+		if req.Response.Request != nil {
+			hop.URL = req.Response.Request.URL.String()
+		}
+		hops = append(hops, hop)
+	}
+
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	return hops
+}
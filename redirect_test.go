@@ -0,0 +1,98 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newEchoAuthServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Token") + "|" + r.Header.Get("Authorization")))
+	}))
+}
+
+func newRedirectServer(target string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target, http.StatusFound)
+	}))
+}
+
+func TestHttpClient_RedirectPolicyStripsCrossHostHeaders(t *testing.T) {
+	target := newEchoAuthServer()
+	defer target.Close()
+	origin := newRedirectServer(target.URL)
+	defer origin.Close()
+
+	client := NewHttpClient(WithRedirectPolicy(RedirectPolicy{}))
+	client.SetHeader(http.MethodGet, "X-Token", "secret")
+	client.SetBasicAuth(http.MethodGet, "user", "pass")
+
+	resp, body, err := client.Get(origin.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "|", string(body))
+
+	chain := RedirectChain(resp)
+	assert.Len(t, chain, 1)
+	assert.Equal(t, http.StatusFound, chain[0].StatusCode)
+	assert.Equal(t, origin.URL, chain[0].URL)
+	assert.Equal(t, target.URL, chain[0].Location)
+}
+
+func TestHttpClient_RedirectPolicyForwardsCrossHostHeaders(t *testing.T) {
+	target := newEchoAuthServer()
+	defer target.Close()
+	origin := newRedirectServer(target.URL)
+	defer origin.Close()
+
+	client := NewHttpClient(WithRedirectPolicy(RedirectPolicy{ForwardHeaders: true, ForwardAuth: true}))
+	client.SetHeader(http.MethodGet, "X-Token", "secret")
+	client.SetBasicAuth(http.MethodGet, "user", "pass")
+
+	_, body, err := client.Get(origin.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret|Basic dXNlcjpwYXNz", string(body))
+}
+
+func TestHttpClient_RedirectPolicyBlocks(t *testing.T) {
+	target := newEchoAuthServer()
+	defer target.Close()
+	origin := newRedirectServer(target.URL)
+	defer origin.Close()
+
+	var loop *httptest.Server
+	loop = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, loop.URL, http.StatusFound)
+	}))
+	defer loop.Close()
+
+	cases := map[string]struct {
+		policy RedirectPolicy
+		addrs  string
+	}{
+		"same host only": {RedirectPolicy{SameHostOnly: true}, origin.URL},
+		"https only":     {RedirectPolicy{HTTPSOnly: true}, origin.URL},
+		"max redirects":  {RedirectPolicy{MaxRedirects: 2}, loop.URL},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := NewHttpClient(WithRedirectPolicy(tc.policy), WithMaxRetries(1))
+			_, _, err := client.Get(tc.addrs)
+			assert.ErrorIs(t, err, ErrRedirectBlocked)
+		})
+	}
+}
+
+func TestHttpClient_RedirectPolicyDisable(t *testing.T) {
+	origin := newRedirectServer("/elsewhere")
+	defer origin.Close()
+
+	client := NewHttpClient(WithRedirectPolicy(RedirectPolicy{Disable: true}))
+	resp, _, err := client.Get(origin.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/elsewhere", resp.Header.Get("Location"))
+	assert.Empty(t, RedirectChain(resp))
+}
@@ -0,0 +1,45 @@
+package httpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request is a request built outside of the client, such as one parsed from
+// a curl command. Header is sent on top of the headers set for the method.
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Do sends req with the retries, hooks and options of the client. The body is
+// always sent as given, even when empty, the form values set for the method
+// are not used.
+// Without a Content-Type header the body is sent as JSON for POST, PUT and
+// PATCH, like the other methods of the client, and as
+// application/octet-stream otherwise.
+func (c *HttpClient) Do(ctx context.Context, req *Request) (*http.Response, []byte, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var contentType string
+	if len(req.Body) > 0 {
+		contentType = req.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = defaultContentType(method)
+		}
+	}
+	return c.doRequestWithContextRaw(ctx, method, req.URL, req.Body, contentType, req.Header, true)
+}
+
+func defaultContentType(method string) string {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return "application/json"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,60 @@
+package httpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_DoIgnoresSharedForms(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Header.Get("Content-Type") + "|" + string(body)))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient()
+	client.SetFormValue(http.MethodPut, "a", "b")
+	client.SetFormValue(http.MethodDelete, "a", "b")
+
+	_, body, err := client.Do(context.Background(), &Request{Method: http.MethodPut, URL: ts.URL, Body: []byte("raw-payload")})
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json|raw-payload", string(body))
+
+	_, body, err = client.Do(context.Background(), &Request{Method: http.MethodDelete, URL: ts.URL, Body: []byte("raw-payload")})
+	assert.NoError(t, err)
+	assert.Equal(t, "application/octet-stream|raw-payload", string(body))
+
+	_, body, err = client.Do(context.Background(), &Request{
+		Method: http.MethodPut,
+		URL:    ts.URL,
+		Header: http.Header{"Content-Type": {"text/plain"}},
+		Body:   []byte("raw-payload"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain|raw-payload", string(body))
+}
+
+func TestHttpClient_DoEmptyBodyIgnoresSharedForms(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Header.Get("Content-Type") + "|" + string(body)))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient()
+	client.SetFormValue(http.MethodPost, "a", "b")
+
+	_, body, err := client.Do(context.Background(), &Request{Method: http.MethodPost, URL: ts.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, "|", string(body))
+
+	// the shared form is still sent by the other methods
+	_, body, err = client.Post(ts.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, formContentType+"|a=b", string(body))
+}
@@ -0,0 +1,303 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver looks up the addresses of a host. *net.Resolver implements it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// TTLResolver is implemented by resolvers that know the record TTL. The DNS
+// cache keeps their answers for the TTL, capped by the configured cache TTL.
+type TTLResolver interface {
+	LookupHostTTL(ctx context.Context, host string) ([]string, time.Duration, error)
+}
+
+type IPPreference int
+
+const (
+	IPPreferenceDefault IPPreference = iota
+	IPPreferenceIPv4
+	IPPreferenceIPv6
+)
+
+const defaultFallbackDelay = 300 * time.Millisecond
+
+func (s *HttpClientParams) customResolution() bool {
+	return len(s.HostOverrides) > 0 || s.Resolver != nil || s.DNSCacheTTL > 0 || s.IPPreference != IPPreferenceDefault
+}
+
+type hostResolver struct {
+	overrides     map[string][]string
+	resolver      Resolver
+	cache         *dnsCache
+	preference    IPPreference
+	fallbackDelay time.Duration
+}
+
+func newHostResolver(s *HttpClientParams) *hostResolver {
+	r := &hostResolver{
+		overrides:     s.HostOverrides,
+		resolver:      s.Resolver,
+		preference:    s.IPPreference,
+		fallbackDelay: s.FallbackDelay,
+	}
+	if r.resolver == nil {
+		r.resolver = net.DefaultResolver
+	}
+	if s.DNSCacheTTL > 0 {
+		r.cache = &dnsCache{
+			ttl:     s.DNSCacheTTL,
+			entries: make(map[string]*dnsCacheEntry),
+		}
+	}
+	return r
+}
+
+// dial resolves addr and dials the resulting addresses, racing the preferred
+// address family against the other one as in RFC 6555.
+func (r *hostResolver) dial(ctx context.Context, network, addr string, dial DialContextFunc) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := r.resolve(ctx, host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	primaries, fallbacks := partitionAddrs(addrs, r.preference)
+	if len(fallbacks) == 0 {
+		return dialSerial(ctx, network, primaries, dial)
+	}
+	delay := r.fallbackDelay
+	if delay <= 0 {
+		delay = defaultFallbackDelay
+	}
+	return dialParallel(ctx, network, primaries, fallbacks, delay, dial)
+}
+
+// resolve returns host:port addresses for host, honouring static overrides
+// and the DNS cache.
+func (r *hostResolver) resolve(ctx context.Context, host, port string) ([]string, error) {
+	host = strings.ToLower(host)
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{net.JoinHostPort(host, port)}, nil
+	}
+
+	ips, ok := r.overrides[net.JoinHostPort(host, port)]
+	if !ok {
+		ips, ok = r.overrides[host]
+	}
+	if !ok {
+		var err error
+		if r.cache != nil {
+			ips, err = r.cache.lookup(ctx, host, r.lookup)
+		} else {
+			ips, _, err = r.lookup(ctx, host)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if _, _, err := net.SplitHostPort(ip); err == nil {
+			addrs = append(addrs, ip)
+			continue
+		}
+		addrs = append(addrs, net.JoinHostPort(ip, port))
+	}
+	return addrs, nil
+}
+
+func (r *hostResolver) lookup(ctx context.Context, host string) ([]string, time.Duration, error) {
+	if ttlResolver, ok := r.resolver.(TTLResolver); ok {
+		return ttlResolver.LookupHostTTL(ctx, host)
+	}
+	addrs, err := r.resolver.LookupHost(ctx, host)
+	return addrs, 0, err
+}
+
+type dnsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*dnsCacheEntry
+}
+
+type dnsCacheEntry struct {
+	ready   chan struct{}
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// lookup returns the cached addresses of host. Concurrent lookups of the same
+// host share a single query.
+func (c *dnsCache) lookup(ctx context.Context, host string, lookup func(context.Context, string) ([]string, time.Duration, error)) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	if ok {
+		select {
+		case <-entry.ready:
+			if time.Now().After(entry.expires) {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		entry = &dnsCacheEntry{ready: make(chan struct{})}
+		c.entries[host] = entry
+		c.mu.Unlock()
+
+		addrs, ttl, err := lookup(ctx, host)
+		if ttl <= 0 || ttl > c.ttl {
+			ttl = c.ttl
+		}
+
+		c.mu.Lock()
+		entry.addrs, entry.err, entry.expires = addrs, err, time.Now().Add(ttl)
+		if err != nil {
+			delete(c.entries, host)
+		}
+		close(entry.ready)
+		c.mu.Unlock()
+		return addrs, err
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-entry.ready:
+		return entry.addrs, entry.err
+	}
+}
+
+// partitionAddrs splits addrs into the preferred address family and the
+// other one. Without a preference the family of the first address wins.
+func partitionAddrs(addrs []string, preference IPPreference) (primaries, fallbacks []string) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	var preferV4 bool
+	switch preference {
+	case IPPreferenceIPv4:
+		preferV4 = true
+	case IPPreferenceIPv6:
+		preferV4 = false
+	default:
+		preferV4 = isIPv4Addr(addrs[0])
+	}
+
+	for _, addr := range addrs {
+		if isIPv4Addr(addr) == preferV4 {
+			primaries = append(primaries, addr)
+		} else {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+	if len(primaries) == 0 {
+		return fallbacks, nil
+	}
+	return primaries, fallbacks
+}
+
+func isIPv4Addr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() != nil
+}
+
+func dialSerial(ctx context.Context, network string, addrs []string, dial DialContextFunc) (net.Conn, error) {
+	var errs []error
+	for _, addr := range addrs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, addr)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no addresses to dial")
+	}
+	return nil, errors.Join(errs...)
+}
+
+type dialResult struct {
+	conn    net.Conn
+	err     error
+	primary bool
+}
+
+func dialParallel(ctx context.Context, network string, primaries, fallbacks []string, delay time.Duration, dial DialContextFunc) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, 2)
+	race := func(addrs []string, primary bool) {
+		conn, err := dialSerial(ctx, network, addrs, dial)
+		results <- dialResult{conn: conn, err: err, primary: primary}
+	}
+
+	go race(primaries, true)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var primaryErr, fallbackErr error
+	started, received := 1, 0
+	startFallback := func() {
+		if started == 1 {
+			started++
+			go race(fallbacks, false)
+		}
+	}
+
+	for {
+		select {
+		case <-timer.C:
+			startFallback()
+		case res := <-results:
+			received++
+			if res.err == nil {
+				if received < started {
+					// the loser may still connect, close it once it does
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if res.primary {
+				primaryErr = res.err
+				startFallback()
+			} else {
+				fallbackErr = res.err
+			}
+			if primaryErr != nil && fallbackErr != nil {
+				return nil, errors.Join(primaryErr, fallbackErr)
+			}
+		}
+	}
+}
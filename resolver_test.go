@@ -0,0 +1,123 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingResolver struct {
+	calls int32
+	addrs []string
+	ttl   time.Duration
+}
+
+func (r *countingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	atomic.AddInt32(&r.calls, 1)
+	return r.addrs, nil
+}
+
+type ttlResolver struct {
+	countingResolver
+}
+
+func (r *ttlResolver) LookupHostTTL(ctx context.Context, host string) ([]string, time.Duration, error) {
+	addrs, err := r.LookupHost(ctx, host)
+	return addrs, r.ttl, err
+}
+
+func TestHttpClient_WithHostOverride(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(WithHostOverride("api.test", ts.Listener.Addr().String()))
+	_, body, err := client.Get("http://api.test/")
+	assert.NoError(t, err)
+	assert.Equal(t, "api.test", string(body))
+}
+
+func TestHttpClient_WithResolver(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("resolved"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	resolver := &countingResolver{addrs: []string{"127.0.0.1"}}
+	client := NewHttpClient(WithResolver(resolver), WithDNSCache(time.Minute))
+	_, body, err := client.Get("http://service.test:" + port + "/")
+	assert.NoError(t, err)
+	assert.Equal(t, "resolved", string(body))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&resolver.calls))
+}
+
+func TestHostResolver_Cache(t *testing.T) {
+	resolver := &countingResolver{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+	r := newHostResolver(newHttpClientParams(WithResolver(resolver), WithDNSCache(time.Minute)))
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.resolve(context.Background(), "svc.test", "443")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1:443", "10.0.0.2:443"}, addrs)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&resolver.calls))
+
+	short := &ttlResolver{countingResolver{addrs: []string{"10.0.0.3"}, ttl: time.Millisecond}}
+	r = newHostResolver(newHttpClientParams(WithResolver(short), WithDNSCache(time.Minute)))
+	_, err := r.resolve(context.Background(), "svc.test", "80")
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = r.resolve(context.Background(), "svc.test", "80")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&short.calls))
+}
+
+func TestPartitionAddrs(t *testing.T) {
+	addrs := []string{"[2001:db8::1]:80", "10.0.0.1:80", "[2001:db8::2]:80"}
+
+	primaries, fallbacks := partitionAddrs(addrs, IPPreferenceDefault)
+	assert.Equal(t, []string{"[2001:db8::1]:80", "[2001:db8::2]:80"}, primaries)
+	assert.Equal(t, []string{"10.0.0.1:80"}, fallbacks)
+
+	primaries, fallbacks = partitionAddrs(addrs, IPPreferenceIPv4)
+	assert.Equal(t, []string{"10.0.0.1:80"}, primaries)
+	assert.Len(t, fallbacks, 2)
+
+	primaries, fallbacks = partitionAddrs([]string{"10.0.0.1:80"}, IPPreferenceIPv6)
+	assert.Equal(t, []string{"10.0.0.1:80"}, primaries)
+	assert.Empty(t, fallbacks)
+}
+
+func TestHostResolver_DialFallsBackToOtherFamily(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	r := newHostResolver(newHttpClientParams(
+		WithHostOverride("dual.test", "2001:db8::1", "127.0.0.1"),
+		WithIPPreference(IPPreferenceIPv6),
+		WithFallbackDelay(time.Second),
+	))
+
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if isIPv4Addr(addr) {
+			return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+		}
+		return nil, errors.New("network unreachable")
+	}
+
+	conn, err := r.dial(context.Background(), "tcp", "dual.test:80", dial)
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"[2001:db8::1]:80", "127.0.0.1:80"}, dialed)
+}
@@ -0,0 +1,131 @@
+package httpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StopReason tells why a call stopped retrying.
+type StopReason string
+
+const (
+	StopRetriesExhausted StopReason = "retries exhausted"
+	StopNonRetryable     StopReason = "non-retryable error"
+	StopContextDone      StopReason = "context done"
+	StopTimeBudget       StopReason = "insufficient time left"
+	StopRetryBudget      StopReason = "retry budget exhausted"
+)
+
+// AttemptRecord describes a failed attempt of a call.
+type AttemptRecord struct {
+	// Attempt is the 1-based attempt number.
+	Attempt    int
+	Err        error
+	StatusCode int
+	Duration   time.Duration
+	// Wait is the time waited after the attempt before the next one.
+	Wait time.Duration
+}
+
+// RetryError is returned when a call fails. It records every attempt and
+// unwraps to each attempt error, so errors.Is and errors.As match any of them.
+type RetryError struct {
+	Attempts []AttemptRecord
+	Reason   StopReason
+	// Cause is set when the call stopped for a reason other than the last
+	// attempt error, e.g. the context was cancelled while waiting.
+	Cause error
+}
+
+func (e *RetryError) Error() string {
+	last := e.Last()
+	if len(e.Attempts) == 1 && e.Cause == nil {
+		return last.Error()
+	}
+	if e.Cause != nil {
+		last = e.Cause
+	}
+	noun := "attempts"
+	if len(e.Attempts) == 1 {
+		noun = "attempt"
+	}
+	return fmt.Sprintf("request failed after %d %s (%s): %v", len(e.Attempts), noun, e.Reason, last)
+}
+
+func (e *RetryError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts)+1)
+	for _, attempt := range e.Attempts {
+		if attempt.Err != nil {
+			errs = append(errs, attempt.Err)
+		}
+	}
+	if e.Cause != nil {
+		errs = append(errs, e.Cause)
+	}
+	return errs
+}
+
+// AttemptCount returns the number of attempts made.
+func (e *RetryError) AttemptCount() int {
+	return len(e.Attempts)
+}
+
+// Last returns the error of the last attempt.
+func (e *RetryError) Last() error {
+	if len(e.Attempts) == 0 {
+		return e.Cause
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+func (e *RetryError) stop(record AttemptRecord, reason StopReason) *RetryError {
+	e.Attempts = append(e.Attempts, record)
+	e.Reason = reason
+	return e
+}
+
+func stopReason(ctx context.Context, retryable bool) StopReason {
+	switch {
+	case ctx.Err() != nil:
+		return StopContextDone
+	case !retryable:
+		return StopNonRetryable
+	default:
+		return StopRetriesExhausted
+	}
+}
+
+func (c *HttpClient) attemptTimeoutFor(method string) time.Duration {
+	if c.params == nil {
+		return 0
+	}
+	if timeout, ok := c.params.MethodAttemptTimeouts[methodKey(method)]; ok {
+		return timeout
+	}
+	return c.params.AttemptTimeout
+}
+
+func (c *HttpClient) totalTimeoutFor(method string) time.Duration {
+	if c.params == nil {
+		return 0
+	}
+	if timeout, ok := c.params.MethodTotalTimeouts[methodKey(method)]; ok {
+		return timeout
+	}
+	return c.params.TotalTimeout
+}
+
+// hasTimeForRetry reports whether the time left before the call deadline is
+// enough to wait and still give the next attempt MinRetryTime.
+func (c *HttpClient) hasTimeForRetry(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+	var minimum time.Duration
+	if c.params != nil {
+		minimum = c.params.MinRetryTime
+	}
+	return time.Until(deadline)-wait > minimum
+}
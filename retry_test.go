@@ -0,0 +1,134 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_RetryErrorRecordsAttempts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("upstream down"))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithMaxRetries(3),
+		WithRetryStatusCodes(http.StatusBadGateway),
+		WithMaxRetryWait(0),
+	)
+
+	_, _, err := client.Get(ts.URL)
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 3, retryErr.AttemptCount())
+	assert.Equal(t, StopRetriesExhausted, retryErr.Reason)
+	assert.Len(t, retryErr.Unwrap(), 3)
+	for i, attempt := range retryErr.Attempts {
+		assert.Equal(t, i+1, attempt.Attempt)
+		assert.Equal(t, http.StatusBadGateway, attempt.StatusCode)
+	}
+
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.True(t, errors.As(retryErr.Last(), &statusErr))
+	assert.Equal(t, "upstream down", string(statusErr.Body))
+	assert.EqualError(t, err, "request failed after 3 attempts (retries exhausted): http error: status(502) upstream down")
+}
+
+func TestHttpClient_RetryErrorNonRetryable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(WithRetryStatusCodes(http.StatusBadGateway))
+	_, _, err := client.Get(ts.URL)
+
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 1, retryErr.AttemptCount())
+	assert.Equal(t, StopNonRetryable, retryErr.Reason)
+	assert.EqualError(t, err, "http error: status(400) ")
+}
+
+func TestHttpClient_RetryErrorContextDone(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithMaxRetryWait(1),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, _, err := client.GetWithContext(ctx, ts.URL)
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, StopContextDone, retryErr.Reason)
+	assert.Equal(t, 1, retryErr.AttemptCount())
+	assert.Equal(t, time.Second, retryErr.Attempts[0].Wait)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHttpClient_AttemptTimeoutRetries(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first attempt is still sleeping when the retry arrives
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithAttemptTimeout(50*time.Millisecond),
+		WithTotalTimeout(time.Second),
+		WithMaxRetryWait(0),
+	)
+
+	_, body, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+}
+
+func TestHttpClient_TotalTimeoutSkipsRetry(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithMaxRetryWait(0),
+		WithMethodTotalTimeout(http.MethodGet, time.Second),
+		WithMinRetryTime(2*time.Second),
+	)
+
+	_, _, err := client.Get(ts.URL)
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, StopTimeBudget, retryErr.Reason)
+	assert.Equal(t, 1, attempts)
+
+	_, _, err = client.Post(ts.URL, nil)
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, StopRetriesExhausted, retryErr.Reason)
+	assert.Equal(t, 4, attempts)
+}
@@ -0,0 +1,194 @@
+package httpc
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryBudgetWindow = 10 * time.Second
+	retryBudgetBuckets       = 10
+)
+
+// RetryBudget limits retries across calls, as in Finagle and gRPC: over the
+// sliding Window, retries may not exceed Percent of the calls plus
+// MinPerSecond retries per second. Once it is spent, failed calls are not
+// retried.
+type RetryBudget struct {
+	// Percent of the calls that may be retried, 0.2 allows 20%.
+	Percent float64
+	// MinPerSecond retries are always allowed, to let low traffic clients
+	// retry.
+	MinPerSecond int
+	// Window is the period the calls and retries are counted over, 10s when
+	// zero. Windows under 10ns are raised to 10ns.
+	Window time.Duration
+	// PerHost keeps a budget per target host instead of one for the client.
+	PerHost bool
+}
+
+// RetryBudgetStats is a snapshot of a retry budget. Host is empty for the
+// client-wide budget.
+type RetryBudgetStats struct {
+	Host      string
+	Requests  int
+	Retries   int
+	Available int
+	// Rejected counts the retries denied since the client was created.
+	Rejected int64
+}
+
+type budgetBucket struct {
+	start    int64
+	requests int
+	retries  int
+}
+
+type retryBudget struct {
+	mu           sync.Mutex
+	percent      float64
+	minPerSecond int
+	window       time.Duration
+	bucketWidth  time.Duration
+	buckets      [retryBudgetBuckets]budgetBucket
+	rejected     int64
+}
+
+func newRetryBudget(config RetryBudget) *retryBudget {
+	window := config.Window
+	switch {
+	case window <= 0:
+		window = defaultRetryBudgetWindow
+	case window < retryBudgetBuckets:
+		// each bucket must span at least a nanosecond
+		window = retryBudgetBuckets
+	}
+	return &retryBudget{
+		percent:      config.Percent,
+		minPerSecond: config.MinPerSecond,
+		window:       window,
+		bucketWidth:  window / retryBudgetBuckets,
+	}
+}
+
+func (b *retryBudget) bucket(now time.Time) *budgetBucket {
+	start := now.UnixNano() / int64(b.bucketWidth)
+	bucket := &b.buckets[start%retryBudgetBuckets]
+	if bucket.start != start {
+		*bucket = budgetBucket{start: start}
+	}
+	return bucket
+}
+
+// totals sums the buckets still inside the window.
+func (b *retryBudget) totals(now time.Time) (requests, retries int) {
+	current := now.UnixNano() / int64(b.bucketWidth)
+	for _, bucket := range b.buckets {
+		if current-bucket.start < retryBudgetBuckets {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	return requests, retries
+}
+
+func (b *retryBudget) available(now time.Time) int {
+	requests, retries := b.totals(now)
+	allowed := float64(b.minPerSecond)*b.window.Seconds() + b.percent*float64(requests)
+	if left := int(allowed) - retries; left > 0 {
+		return left
+	}
+	return 0
+}
+
+func (b *retryBudget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket(time.Now()).requests++
+}
+
+// withdraw takes a retry from the budget, reporting false when it is spent.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.available(now) < 1 {
+		b.rejected++
+		return false
+	}
+	b.bucket(now).retries++
+	return true
+}
+
+func (b *retryBudget) stats(host string) RetryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	requests, retries := b.totals(now)
+	return RetryBudgetStats{
+		Host:      host,
+		Requests:  requests,
+		Retries:   retries,
+		Available: b.available(now),
+		Rejected:  b.rejected,
+	}
+}
+
+type retryBudgets struct {
+	mu      sync.Mutex
+	config  RetryBudget
+	budgets map[string]*retryBudget
+}
+
+func newRetryBudgets(config RetryBudget) *retryBudgets {
+	return &retryBudgets{
+		config:  config,
+		budgets: make(map[string]*retryBudget),
+	}
+}
+
+func (r *retryBudgets) budgetFor(addrs string) *retryBudget {
+	var host string
+	if r.config.PerHost {
+		if u, err := url.Parse(addrs); err == nil {
+			host = strings.ToLower(u.Host)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	budget, ok := r.budgets[host]
+	if !ok {
+		budget = newRetryBudget(r.config)
+		r.budgets[host] = budget
+	}
+	return budget
+}
+
+// RetryBudgetStats returns the state of the retry budgets, sorted by host.
+func (c *HttpClient) RetryBudgetStats() []RetryBudgetStats {
+	if c.retryBudgets == nil {
+		return nil
+	}
+
+	c.retryBudgets.mu.Lock()
+	hosts := make([]string, 0, len(c.retryBudgets.budgets))
+	budgets := make(map[string]*retryBudget, len(c.retryBudgets.budgets))
+	for host, budget := range c.retryBudgets.budgets {
+		hosts = append(hosts, host)
+		budgets[host] = budget
+	}
+	c.retryBudgets.mu.Unlock()
+
+	sort.Strings(hosts)
+	stats := make([]RetryBudgetStats, 0, len(hosts))
+	for _, host := range hosts {
+		stats = append(stats, budgets[host].stats(host))
+	}
+	return stats
+}
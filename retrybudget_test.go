@@ -0,0 +1,128 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpClient_RetryBudget(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithMaxRetries(3),
+		WithRetryStatusCodes(http.StatusBadGateway),
+		WithMaxRetryWait(0),
+		WithRetryBudget(RetryBudget{Percent: 0.5, Window: time.Minute}),
+	)
+
+	// the first call earns half a retry, not enough to retry
+	_, _, err := client.Get(ts.URL)
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 1, retryErr.AttemptCount())
+	assert.Equal(t, StopRetryBudget, retryErr.Reason)
+
+	// the second call completes a retry and spends it
+	_, _, err = client.Get(ts.URL)
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 2, retryErr.AttemptCount())
+	assert.Equal(t, StopRetryBudget, retryErr.Reason)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&hits))
+
+	assert.Equal(t, []RetryBudgetStats{{
+		Requests:  2,
+		Retries:   1,
+		Available: 0,
+		Rejected:  2,
+	}}, client.RetryBudgetStats())
+}
+
+func TestHttpClient_RetryBudgetPerHost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	client := NewHttpClient(
+		WithMaxRetries(2),
+		WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithMaxRetryWait(0),
+		WithRetryBudget(RetryBudget{MinPerSecond: 1, Window: time.Second, PerHost: true}),
+	)
+
+	for i := 0; i < 2; i++ {
+		client.Get(first.URL)
+	}
+	_, _, err := client.Get(second.URL)
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, StopRetriesExhausted, retryErr.Reason)
+
+	stats := client.RetryBudgetStats()
+	assert.Len(t, stats, 2)
+	byHost := map[string]RetryBudgetStats{}
+	for _, stat := range stats {
+		byHost[stat.Host] = stat
+	}
+	firstURL, _ := url.Parse(first.URL)
+	assert.Equal(t, 2, byHost[firstURL.Host].Requests)
+	assert.Equal(t, 1, byHost[firstURL.Host].Retries)
+	assert.EqualValues(t, 1, byHost[firstURL.Host].Rejected)
+	secondURL, _ := url.Parse(second.URL)
+	assert.Equal(t, 1, byHost[secondURL.Host].Retries)
+}
+
+func TestHttpClient_RetryBudgetStatsDisabled(t *testing.T) {
+	assert.Nil(t, NewHttpClient().RetryBudgetStats())
+}
+
+func TestRetryBudget_TinyWindow(t *testing.T) {
+	budget := newRetryBudget(RetryBudget{Percent: 1, Window: time.Nanosecond})
+	assert.Equal(t, time.Nanosecond, budget.bucketWidth)
+	assert.NotPanics(t, func() {
+		budget.recordRequest()
+		budget.withdraw()
+		budget.stats("")
+	})
+}
+
+func TestHttpClient_RetryBudgetKeepsTokenOnTimeBudgetStop(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithRetryStatusCodes(http.StatusBadGateway),
+		WithMaxRetryWait(0),
+		WithRetryBudget(RetryBudget{Percent: 1, Window: time.Minute}),
+		WithMethodTotalTimeout(http.MethodGet, time.Second),
+		WithMinRetryTime(2*time.Second),
+	)
+
+	_, _, err := client.Get(ts.URL)
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, StopTimeBudget, retryErr.Reason)
+
+	// the retry was never made, so no token was spent on it
+	stats := client.RetryBudgetStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, 0, stats[0].Retries)
+	assert.EqualValues(t, 0, stats[0].Rejected)
+}
@@ -0,0 +1,134 @@
+package httpc
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigV4Signer_TestSuiteVectors(t *testing.T) {
+	signer := &SigV4Signer{
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:    "us-east-1",
+		Service:   "service",
+		Now: func() time.Time {
+			return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+		},
+	}
+
+	cases := map[string]string{
+		"http://example.amazonaws.com/":                             "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		"http://example.amazonaws.com/?Param2=value2&Param1=value1": "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	}
+	for addrs, signature := range cases {
+		req, err := http.NewRequest(http.MethodGet, addrs, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, signer.Sign(req, nil))
+
+		assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+		assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+			"SignedHeaders=host;x-amz-date, Signature="+signature, req.Header.Get("Authorization"))
+	}
+}
+
+func TestS3Signer_SetsContentSHA256(t *testing.T) {
+	signer := NewS3Signer("minio", "minio123", "us-east-1")
+	req, err := http.NewRequest(http.MethodPut, "http://localhost:9000/bucket/my%20key", strings.NewReader("data"))
+	assert.NoError(t, err)
+	assert.NoError(t, signer.Sign(req, []byte("data")))
+
+	assert.Equal(t, PayloadHash([]byte("data")), req.Header.Get("X-Amz-Content-Sha256"))
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date")
+
+	canonical, _ := signer.CanonicalRequest(req, PayloadHash([]byte("data")))
+	assert.True(t, strings.HasPrefix(canonical, "PUT\n/bucket/my%20key\n"))
+}
+
+func TestSigV4Signer_CanonicalURIReservedCharacters(t *testing.T) {
+	key := "2024-01-01T10:00:00+00:00,v=1 $&@;.txt"
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:9000/bucket/"+url.PathEscape(key), nil)
+	assert.NoError(t, err)
+
+	s3 := NewS3Signer("minio", "minio123", "us-east-1")
+	canonical, _ := s3.CanonicalRequest(req, PayloadHash(nil))
+	assert.True(t, strings.HasPrefix(canonical,
+		"GET\n/bucket/2024-01-01T10%3A00%3A00%2B00%3A00%2Cv%3D1%20%24%26%40%3B.txt\n"), canonical)
+
+	service := &SigV4Signer{AccessKey: "a", SecretKey: "s", Region: "us-east-1", Service: "execute-api"}
+	canonical, _ = service.CanonicalRequest(req, PayloadHash(nil))
+	assert.True(t, strings.HasPrefix(canonical,
+		"GET\n/bucket/2024-01-01T10%253A00%253A00%252B00%253A00%252Cv%253D1%2520%2524%2526%2540%253B.txt\n"), canonical)
+}
+
+func TestSigV4Signer_KeepsHeaderValues(t *testing.T) {
+	signer := NewS3Signer("minio", "minio123", "us-east-1")
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:9000/bucket", nil)
+	assert.NoError(t, err)
+	req.Header.Add("X-Multi", "a    b")
+	req.Header.Add("X-Multi", "  c ")
+	assert.NoError(t, signer.Sign(req, nil))
+
+	// the canonical form collapses spaces, the sent values are untouched
+	assert.Equal(t, []string{"a    b", "  c "}, req.Header.Values("X-Multi"))
+	canonical, _ := signer.CanonicalRequest(req, PayloadHash(nil))
+	assert.Contains(t, canonical, "x-multi:a b,c\n")
+}
+
+func TestHttpClient_RequestSignerRunsOnEveryAttempt(t *testing.T) {
+	signer := &HMACSigner{
+		KeyID:         "svc",
+		Secret:        []byte("secret"),
+		SignedHeaders: []string{"X-Req-Hook"},
+	}
+
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+
+		canonical, signedHeaders := signer.CanonicalRequest(r, PayloadHash(body))
+		expected := "HMAC-SHA256 KeyId=svc,SignedHeaders=" + signedHeaders + ",Signature=" +
+			base64.StdEncoding.EncodeToString(hmacSHA256(signer.Secret, []byte(canonical)))
+		if r.Header.Get("Authorization") != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if attempts < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(signedHeaders))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithRequestHook(func(req *http.Request) {
+			req.Header.Set("X-Req-Hook", "hooked")
+		}),
+		WithRequestSigner(signer),
+		WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithMaxRetryWait(0),
+	)
+
+	_, body, err := client.Post(ts.URL+"/orders?b=2&a=1", []byte(`{"id":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "x-req-hook", string(body))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestHttpClient_RequestSignerError(t *testing.T) {
+	client := NewHttpClient(WithRequestSigner(RequestSignerFunc(func(*http.Request, []byte) error {
+		return assert.AnError
+	})))
+
+	_, _, err := client.Get("http://localhost")
+	assert.ErrorIs(t, err, assert.AnError)
+}
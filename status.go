@@ -0,0 +1,56 @@
+package httpc
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SuccessPredicate reports whether a response is a successful outcome. Other
+// responses are returned as a *StatusError.
+type SuccessPredicate func(*http.Response) bool
+
+// StatusError is returned when a response does not satisfy the success
+// predicate, by default when the status is 400 or above.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http error: status(%d) %s", e.StatusCode, string(e.Body))
+}
+
+func defaultSuccessPredicate(resp *http.Response) bool {
+	return resp.StatusCode < 400
+}
+
+// errorOnStatus returns a predicate that only fails the listed statuses.
+func errorOnStatus(codes ...int) SuccessPredicate {
+	failures := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		failures[code] = struct{}{}
+	}
+	return func(resp *http.Response) bool {
+		_, failed := failures[resp.StatusCode]
+		return !failed
+	}
+}
+
+func (c *HttpClient) isSuccess(method string, resp *http.Response) bool {
+	if c.params == nil {
+		return defaultSuccessPredicate(resp)
+	}
+	if predicate, ok := c.params.MethodSuccessPredicates[methodKey(method)]; ok {
+		return predicate(resp)
+	}
+	if c.params.SuccessPredicate != nil {
+		return c.params.SuccessPredicate(resp)
+	}
+	return defaultSuccessPredicate(resp)
+}
+
+func (c *HttpClient) responseOnError() bool {
+	return c.params != nil && c.params.ResponseOnError
+}
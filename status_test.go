@@ -0,0 +1,70 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStatusServer(status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(status)
+		w.Write([]byte("payload"))
+	}))
+}
+
+func TestHttpClient_StatusErrorByDefault(t *testing.T) {
+	ts := newStatusServer(http.StatusNotFound)
+	defer ts.Close()
+
+	client := NewHttpClient()
+	resp, body, err := client.Get(ts.URL)
+	assert.Nil(t, resp)
+	assert.Nil(t, body)
+
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, "payload", string(statusErr.Body))
+	assert.Equal(t, "req-1", statusErr.Header.Get("X-Request-Id"))
+	assert.EqualError(t, err, "http error: status(404) payload")
+}
+
+func TestHttpClient_WithResponseOnError(t *testing.T) {
+	ts := newStatusServer(http.StatusNotFound)
+	defer ts.Close()
+
+	client := NewHttpClient(WithResponseOnError(true))
+	resp, body, err := client.Get(ts.URL)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "req-1", resp.Header.Get("X-Request-Id"))
+	assert.Equal(t, "payload", string(body))
+}
+
+func TestHttpClient_SuccessPredicates(t *testing.T) {
+	notFound := newStatusServer(http.StatusNotFound)
+	defer notFound.Close()
+	accepted := newStatusServer(http.StatusAccepted)
+	defer accepted.Close()
+
+	client := NewHttpClient(
+		WithErrorOnStatus(http.StatusInternalServerError),
+		WithMethodSuccessPredicate(http.MethodPost, func(resp *http.Response) bool {
+			return resp.StatusCode == http.StatusCreated
+		}),
+	)
+
+	resp, body, err := client.Get(notFound.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "payload", string(body))
+
+	_, _, err = client.Post(accepted.URL, nil)
+	var statusErr *StatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusAccepted, statusErr.StatusCode)
+}
@@ -0,0 +1,187 @@
+package httpc
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings break down where the time of a call went. The phases describe the
+// last attempt, a phase that did not happen, such as DNS on a reused
+// connection, is zero.
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// TimeToFirstByte runs from the start of the attempt to the first
+	// response byte, ServerProcessing from the request being written to it.
+	TimeToFirstByte  time.Duration
+	ServerProcessing time.Duration
+	// BodyTransfer runs from the first response byte until the body is read
+	// or closed.
+	BodyTransfer time.Duration
+
+	ConnReused  bool
+	ConnWasIdle bool
+	IdleTime    time.Duration
+
+	// Attempt is the duration of the last attempt, Total the duration of the
+	// call across all attempts and Attempts their count.
+	Attempt  time.Duration
+	Total    time.Duration
+	Attempts int
+}
+
+// WithTimings traces every attempt with net/http/httptrace, see TimingsFrom.
+func WithTimings(enabled bool) HttpClientOptions {
+	return func(s *HttpClientParams) {
+		s.Timings = enabled
+	}
+}
+
+type timingsKey struct{}
+
+// TimingsFrom returns the timings of the call that returned resp, nil when
+// the client was not created with WithTimings. The body phases are complete
+// once the body is read or closed.
+func TimingsFrom(resp *http.Response) *Timings {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+	recorder, _ := resp.Request.Context().Value(timingsKey{}).(*timingsRecorder)
+	if recorder == nil {
+		return nil
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	timings := recorder.timings
+	return &timings
+}
+
+// timingsRecorder collects the timings of a call, an attempt at a time.
+type timingsRecorder struct {
+	mu      sync.Mutex
+	timings Timings
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wrote        time.Time
+	firstByte    time.Time
+}
+
+func (c *HttpClient) timingsEnabled() bool {
+	return c.params != nil && c.params.Timings
+}
+
+// trace resets the attempt phases and returns ctx traced into the recorder.
+func (r *timingsRecorder) trace(ctx context.Context, attempt int) context.Context {
+	r.mu.Lock()
+	r.timings = Timings{Total: r.timings.Total, Attempts: attempt}
+	r.start = time.Now()
+	r.dnsStart, r.connectStart, r.tlsStart = time.Time{}, time.Time{}, time.Time{}
+	r.wrote, r.firstByte = time.Time{}, time.Time{}
+	r.mu.Unlock()
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.mark(&r.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.since(r.dnsStart, &r.timings.DNS)
+		},
+		ConnectStart: func(string, string) {
+			r.mark(&r.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			r.since(r.connectStart, &r.timings.Connect)
+		},
+		TLSHandshakeStart: func() {
+			r.mark(&r.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.since(r.tlsStart, &r.timings.TLS)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.timings.ConnReused = info.Reused
+			r.timings.ConnWasIdle = info.WasIdle
+			r.timings.IdleTime = info.IdleTime
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			r.mark(&r.wrote)
+		},
+		GotFirstResponseByte: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.firstByte = time.Now()
+			r.timings.TimeToFirstByte = r.firstByte.Sub(r.start)
+			if !r.wrote.IsZero() {
+				r.timings.ServerProcessing = r.firstByte.Sub(r.wrote)
+			}
+		},
+	})
+}
+
+func (r *timingsRecorder) mark(t *time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+}
+
+func (r *timingsRecorder) since(start time.Time, phase *time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !start.IsZero() {
+		*phase = time.Since(start)
+	}
+}
+
+// bodyDone completes the attempt once its response body is read or closed.
+func (r *timingsRecorder) bodyDone() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timings.Attempt > 0 {
+		return
+	}
+	now := time.Now()
+	if !r.firstByte.IsZero() {
+		r.timings.BodyTransfer = now.Sub(r.firstByte)
+	}
+	r.timings.Attempt = now.Sub(r.start)
+}
+
+func (r *timingsRecorder) finish(total time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timings.Total = total
+}
+
+// timedBody reports when a response body is fully read or closed.
+type timedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
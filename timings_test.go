@@ -0,0 +1,75 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_Timings(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(
+		WithTimings(true),
+		WithRetryStatusCodes(http.StatusBadGateway),
+		WithMaxRetryWait(0),
+	)
+
+	resp, _, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+
+	timings := TimingsFrom(resp)
+	if !assert.NotNil(t, timings) {
+		return
+	}
+	assert.Equal(t, 2, timings.Attempts)
+	// the retry reuses the connection of the first attempt
+	assert.True(t, timings.ConnReused)
+	assert.Zero(t, timings.Connect)
+	assert.True(t, timings.TimeToFirstByte >= 20*time.Millisecond)
+	assert.True(t, timings.ServerProcessing >= 20*time.Millisecond)
+	assert.True(t, timings.Attempt >= timings.TimeToFirstByte)
+	assert.True(t, timings.Total >= timings.Attempt)
+}
+
+func TestHttpClient_TimingsNewConnection(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(WithTimings(true))
+	resp, _, err := client.Get(strings.Replace(ts.URL, "127.0.0.1", "localhost", 1))
+	assert.NoError(t, err)
+
+	timings := TimingsFrom(resp)
+	assert.False(t, timings.ConnReused)
+	assert.True(t, timings.DNS > 0)
+	assert.True(t, timings.Connect > 0)
+	assert.True(t, timings.BodyTransfer > 0)
+	assert.Equal(t, 1, timings.Attempts)
+}
+
+func TestTimingsFrom_Disabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	resp, _, err := NewHttpClient().Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Nil(t, TimingsFrom(resp))
+	assert.Nil(t, TimingsFrom(nil))
+}
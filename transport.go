@@ -0,0 +1,62 @@
+package httpc
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// newTransport returns the transport configured by the proxy, dialer, DNS
+// and TLS options, or nil when none is set so the client shares
+// http.DefaultTransport and its connection pool.
+func newTransport(params *HttpClientParams) *http.Transport {
+	dial := params.dialContext()
+	if dial == nil && !params.proxyConfigured() && !params.InsecureSkipVerify {
+		return nil
+	}
+
+	var transport *http.Transport
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = base.Clone()
+	} else {
+		// http.DefaultTransport was replaced, start from its defaults
+		transport = &http.Transport{
+			DialContext:           newNetDialer().DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		}
+	}
+	transport.Proxy = params.proxyFunc()
+	if dial != nil {
+		transport.DialContext = dial
+	}
+	if params.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return transport
+}
+
+// roundTripper returns the transport, set by WithTransport or built from the
+// params, wrapped in the transport middlewares. It is nil, for
+// http.DefaultTransport, when no option needs a transport of its own.
+func (s *HttpClientParams) roundTripper() http.RoundTripper {
+	rt := s.Transport
+	if rt == nil {
+		if transport := newTransport(s); transport != nil {
+			rt = transport
+		}
+	}
+	if len(s.TransportMiddlewares) == 0 {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for _, middleware := range s.TransportMiddlewares {
+		rt = middleware(rt)
+	}
+	return rt
+}
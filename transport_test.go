@@ -0,0 +1,87 @@
+package httpc
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestHttpClient_WithTransportMiddleware(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Join(r.Header.Values("X-Chain"), ",")))
+	}))
+	defer ts.Close()
+
+	tag := func(name string) TransportMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				req.Header.Add("X-Chain", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	client := NewHttpClient(WithTransportMiddleware(tag("inner")), WithTransportMiddleware(tag("outer")))
+
+	_, body, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "outer,inner", string(body))
+}
+
+func TestHttpClient_WithTransport(t *testing.T) {
+	var calls int
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		assert.Equal(t, "outer", req.Header.Get("X-Chain"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("from memory")),
+			Request:    req,
+		}, nil
+	})
+	tag := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Chain", "outer")
+			return next.RoundTrip(req)
+		})
+	}
+	client := NewHttpClient(WithTransport(transport), WithTransportMiddleware(tag))
+
+	_, body, err := client.Get("http://unreachable.invalid/")
+	require.NoError(t, err)
+	assert.Equal(t, "from memory", string(body))
+	assert.Equal(t, 1, calls)
+}
+
+func TestNewHttpClient_SharesDefaultTransport(t *testing.T) {
+	assert.Nil(t, NewHttpClient().client.Transport)
+	assert.NotNil(t, NewHttpClient(WithProxy("http://proxy.local:3128")).client.Transport)
+}
+
+func TestNewHttpClient_ReplacedDefaultTransport(t *testing.T) {
+	defer func(transport http.RoundTripper) { http.DefaultTransport = transport }(http.DefaultTransport)
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, io.EOF
+	})
+
+	assert.NotPanics(t, func() {
+		client := NewHttpClient(WithInsecureSkipVerify(true))
+		transport, ok := client.client.Transport.(*http.Transport)
+		assert.True(t, ok)
+		assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+		assert.NotNil(t, transport.DialContext)
+	})
+}
@@ -0,0 +1,129 @@
+package httpc
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PathParams holds the values of the {name} placeholders of a path template.
+type PathParams map[string]string
+
+// Query builds query strings with typed values. Repeated keys are kept in
+// insertion order.
+type Query struct {
+	values url.Values
+}
+
+func NewQuery() *Query {
+	return &Query{values: url.Values{}}
+}
+
+func (q *Query) Add(key string, values ...string) *Query {
+	for _, value := range values {
+		q.values.Add(key, value)
+	}
+	return q
+}
+
+func (q *Query) Set(key, value string) *Query {
+	q.values.Set(key, value)
+	return q
+}
+
+func (q *Query) AddInt(key string, value int64) *Query {
+	return q.Add(key, strconv.FormatInt(value, 10))
+}
+
+func (q *Query) AddFloat(key string, value float64) *Query {
+	return q.Add(key, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (q *Query) AddBool(key string, value bool) *Query {
+	return q.Add(key, strconv.FormatBool(value))
+}
+
+// AddTime formats value with layout, time.RFC3339 when empty.
+func (q *Query) AddTime(key string, value time.Time, layout string) *Query {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return q.Add(key, value.Format(layout))
+}
+
+func (q *Query) Del(key string) *Query {
+	q.values.Del(key)
+	return q
+}
+
+func (q *Query) Values() url.Values {
+	return cloneValues(q.values)
+}
+
+func (q *Query) Encode() string {
+	return q.values.Encode()
+}
+
+// ExpandPath replaces the {name} placeholders of template with the escaped
+// path segment of params[name], e.g. "/users/{id}/orders/{orderID}". Empty,
+// "." and ".." values are rejected, as they would change the path once it is
+// normalized.
+func ExpandPath(template string, params PathParams) (string, error) {
+	var b strings.Builder
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("path template %q: unclosed placeholder", template)
+		}
+		name := rest[start+1 : start+end]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("path template %q: missing value for {%s}", template, name)
+		}
+		switch value {
+		case "", ".", "..":
+			return "", fmt.Errorf("path template %q: invalid value %q for {%s}", template, value, name)
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(url.PathEscape(value))
+		rest = rest[start+end+1:]
+	}
+}
+
+// BuildURL expands template and appends the encoded query, if any.
+func BuildURL(template string, params PathParams, query *Query) (string, error) {
+	addrs, err := ExpandPath(template, params)
+	if err != nil {
+		return "", err
+	}
+	if query == nil || len(query.values) == 0 {
+		return addrs, nil
+	}
+	separator := "?"
+	if strings.Contains(addrs, "?") {
+		separator = "&"
+	}
+	return addrs + separator + query.Encode(), nil
+}
+
+// resolveURL joins relative addresses to the configured base URL.
+func (c *HttpClient) resolveURL(addrs string) string {
+	if c.params == nil || c.params.BaseURL == "" {
+		return addrs
+	}
+	if u, err := url.Parse(addrs); err == nil && u.IsAbs() {
+		return addrs
+	}
+	if addrs == "" {
+		return c.params.BaseURL
+	}
+	return strings.TrimRight(c.params.BaseURL, "/") + "/" + strings.TrimLeft(addrs, "/")
+}
@@ -0,0 +1,67 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPath(t *testing.T) {
+	path, err := ExpandPath("/users/{id}/orders/{orderID}", PathParams{
+		"id":      "john/doe",
+		"orderID": "a b?c",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/john%2Fdoe/orders/a%20b%3Fc", path)
+
+	_, err = ExpandPath("/users/{id}", PathParams{})
+	assert.Error(t, err)
+
+	_, err = ExpandPath("/users/{id", PathParams{"id": "1"})
+	assert.Error(t, err)
+
+	for _, value := range []string{"", ".", ".."} {
+		_, err = ExpandPath("/users/{id}/orders", PathParams{"id": value})
+		assert.Error(t, err, value)
+	}
+	path, err = ExpandPath("/files/{name}", PathParams{"name": "..."})
+	assert.NoError(t, err)
+	assert.Equal(t, "/files/...", path)
+}
+
+func TestBuildURL(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	query := NewQuery().
+		Add("tag", "a", "b").
+		AddBool("active", true).
+		AddInt("limit", 10).
+		AddTime("since", since, "").
+		AddTime("day", since, "2006-01-02")
+
+	addrs, err := BuildURL("/users/{id}?expand=orders", PathParams{"id": "42"}, query)
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/42?expand=orders&active=true&day=2024-01-02&limit=10&since=2024-01-02T03%3A04%3A05Z&tag=a&tag=b", addrs)
+	assert.Equal(t, []string{"a", "b"}, query.Values()["tag"])
+}
+
+func TestHttpClient_WithBaseURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer ts.Close()
+
+	client := NewHttpClient(WithBaseURL(ts.URL + "/api/"))
+	addrs, err := BuildURL("/users/{id}", PathParams{"id": "a/b"}, NewQuery().Add("q", "x y"))
+	assert.NoError(t, err)
+
+	_, body, err := client.Get(addrs)
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/users/a%2Fb?q=x+y", string(body))
+
+	_, body, err = client.Get(ts.URL + "/absolute")
+	assert.NoError(t, err)
+	assert.Equal(t, "/absolute", string(body))
+}